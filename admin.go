@@ -0,0 +1,219 @@
+package log4g
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SinkHealth is a snapshot of the state of a sink.
+type SinkHealth struct {
+	OpenFiles  int    `json:"open_files"`
+	QueueDepth int    `json:"queue_depth"`
+	Dropped    uint64 `json:"dropped"`
+}
+
+// TopicLevel is the logging level of a topic.
+type TopicLevel struct {
+	Topic string `json:"topic"`
+	Level string `json:"level"`
+	// Overridden is true when the level was set at runtime.
+	Overridden bool `json:"overridden"`
+	// Expires is the time the level reverts to the default, nil for never.
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// topicOverride stores a level set at runtime.
+type topicOverride struct {
+	level   string
+	expires time.Time
+}
+
+// LevelController changes the logging level of LoggerFactory topics at runtime.
+type LevelController struct {
+	DefaultLevel string
+	lock         sync.Mutex
+	topics       map[string]*topicOverride
+	now          func() time.Time
+}
+
+// NewLevelController creates a LevelController, topics log at defaultLevel until set otherwise.
+func NewLevelController(defaultLevel string) *LevelController {
+	return &LevelController{
+		DefaultLevel: defaultLevel,
+		topics:       make(map[string]*topicOverride),
+		now:          time.Now,
+	}
+}
+
+// level returns the effective level of the topic.
+// Expired overrides are reverted.
+// lock must be held.
+func (lc *LevelController) level(topic string) (string, *topicOverride) {
+	override, ok := lc.topics[topic]
+	if !ok || override == nil {
+		return lc.DefaultLevel, nil
+	}
+	if !override.expires.IsZero() && !lc.now().Before(override.expires) {
+		lc.topics[topic] = nil
+		return lc.DefaultLevel, nil
+	}
+	return override.level, override
+}
+
+// Level returns the effective level of the topic.
+func (lc *LevelController) Level(topic string) string {
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+	level, _ := lc.level(topic)
+	return level
+}
+
+// SetLevel sets the level of the topic.
+// The level reverts to the default after ttl, a ttl of 0 never reverts.
+func (lc *LevelController) SetLevel(topic string, level string, ttl time.Duration) {
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+	override := &topicOverride{level: level}
+	if ttl > 0 {
+		override.expires = lc.now().Add(ttl)
+	}
+	lc.topics[topic] = override
+}
+
+// Reset reverts the topic to the default level.
+func (lc *LevelController) Reset(topic string) {
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+	lc.topics[topic] = nil
+}
+
+// Topics lists the known topics sorted by name.
+func (lc *LevelController) Topics() []TopicLevel {
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+	topics := make([]TopicLevel, 0, len(lc.topics))
+	for topic := range lc.topics {
+		level, override := lc.level(topic)
+		topicLevel := TopicLevel{
+			Topic:      topic,
+			Level:      LevelName(level),
+			Overridden: override != nil,
+		}
+		if override != nil && !override.expires.IsZero() {
+			expires := override.expires
+			topicLevel.Expires = &expires
+		}
+		topics = append(topics, topicLevel)
+	}
+	sort.Slice(topics, func(i, j int) bool {
+		return topics[i].Topic < topics[j].Topic
+	})
+	return topics
+}
+
+// Wrap returns a LoggerFactory whose topics are filtered by the controller.
+// Topics are known to the controller once requested from the factory.
+func (lc *LevelController) Wrap(lf LoggerFactory) LoggerFactory {
+	return func(topic string) Logger {
+		lc.lock.Lock()
+		if _, ok := lc.topics[topic]; !ok {
+			lc.topics[topic] = nil
+		}
+		lc.lock.Unlock()
+		logger := lf(topic)
+		return func(level string, values ...interface{}) {
+			if enabled(lc.Level(topic), level) {
+				logger(level, values...)
+			}
+		}
+	}
+}
+
+// AdminHandler is an http.Handler for inspecting and changing logging levels at runtime.
+//
+//	GET    .../levels                                  lists the topics and their levels.
+//	PUT    .../levels?topic=db&level=DEBUG&ttl=10m     sets the level of a topic.
+//	DELETE .../levels?topic=db                         reverts a topic to the default level.
+//	GET    .../health                                  reports the health of the sinks.
+type AdminHandler struct {
+	Levels *LevelController
+	// Sinks maps sink names to their health reporting function.
+	Sinks map[string]func() SinkHealth
+}
+
+// ServeHTTP implements http.Handler.
+func (ah AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch path.Base(r.URL.Path) {
+	case "levels":
+		ah.serveLevels(w, r)
+	case "health":
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		health := make(map[string]SinkHealth, len(ah.Sinks))
+		for name, sinkHealth := range ah.Sinks {
+			health[name] = sinkHealth()
+		}
+		writeJSON(w, health)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveLevels serves the levels endpoint.
+func (ah AdminHandler) serveLevels(w http.ResponseWriter, r *http.Request) {
+	if ah.Levels == nil {
+		http.Error(w, "no level controller", http.StatusNotFound)
+		return
+	}
+	query := r.URL.Query()
+	topic := query.Get("topic")
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, ah.Levels.Topics())
+		return
+	case http.MethodPut, http.MethodPost:
+		level, err := ParseLevel(query.Get("level"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var ttl time.Duration
+		if query.Get("ttl") != "" {
+			ttl, err = time.ParseDuration(query.Get("ttl"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if topic == "" {
+			http.Error(w, "missing topic", http.StatusBadRequest)
+			return
+		}
+		ah.Levels.SetLevel(topic, level, ttl)
+	case http.MethodDelete:
+		if topic == "" {
+			http.Error(w, "missing topic", http.StatusBadRequest)
+			return
+		}
+		ah.Levels.Reset(topic)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, ah.Levels.Topics())
+}
+
+// writeJSON writes v as the JSON response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
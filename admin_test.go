@@ -0,0 +1,75 @@
+package log4g
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelController(t *testing.T) {
+	now := time.Date(2018, 11, 29, 0, 0, 0, 0, time.UTC)
+	lc := NewLevelController(INFO)
+	lc.now = func() time.Time { return now }
+	logger, buffer := NewInMemoryLogger()
+	loggerFactory := lc.Wrap(func(topic string) Logger {
+		return logger.PrependString(topic)
+	})
+	db := loggerFactory("db")
+	db(DEBUG, "hidden")
+	lc.SetLevel("db", DEBUG, time.Minute)
+	db(DEBUG, "shown")
+	now = now.Add(time.Minute)
+	db(DEBUG, "reverted")
+	assert.Equal(t, []string{"[DEBUG] db shown "}, buffer.StringArray(" "))
+	lc.SetLevel("db", ERROR, 0)
+	assert.Equal(t, ERROR, lc.Level("db"))
+	lc.Reset("db")
+	assert.Equal(t, INFO, lc.Level("db"))
+}
+
+func TestAdminHandler(t *testing.T) {
+	lc := NewLevelController(INFO)
+	lc.Wrap(func(topic string) Logger { return T() })("db")
+	dirLogger := &DirLogger{OpenFiles: make([]FileWritingContext, 2)}
+	handler := AdminHandler{
+		Levels: lc,
+		Sinks:  map[string]func() SinkHealth{"dir": dirLogger.Health},
+	}
+	serve := func(method string, target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+		return recorder
+	}
+	t.Run("levels", func(t *testing.T) {
+		recorder := serve(http.MethodPut, "/admin/log/levels?topic=db&level=debug&ttl=1h")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var topics []TopicLevel
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &topics))
+		assert.Equal(t, 1, len(topics))
+		assert.Equal(t, "db", topics[0].Topic)
+		assert.Equal(t, "DEBUG", topics[0].Level)
+		assert.True(t, topics[0].Overridden)
+		assert.NotNil(t, topics[0].Expires)
+		assert.Equal(t, DEBUG, lc.Level("db"))
+		recorder = serve(http.MethodDelete, "/admin/log/levels?topic=db")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, INFO, lc.Level("db"))
+	})
+	t.Run("errors", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/levels?topic=db&level=verbose").Code)
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/levels?topic=db&level=info&ttl=soon").Code)
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/levels?level=info").Code)
+		assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPatch, "/levels").Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/unknown").Code)
+	})
+	t.Run("health", func(t *testing.T) {
+		recorder := serve(http.MethodGet, "/health")
+		var health map[string]SinkHealth
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &health))
+		assert.Equal(t, map[string]SinkHealth{"dir": {OpenFiles: 2}}, health)
+	})
+}
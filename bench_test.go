@@ -0,0 +1,78 @@
+package log4g
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func BenchmarkPrepend(b *testing.B) {
+	logger := T().Prepend("prepend").Append("append")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger(INFO, "hello", "world")
+	}
+}
+
+func BenchmarkPrependTime(b *testing.B) {
+	logger := T().PrependTime()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger(INFO, "hello", "world")
+	}
+}
+
+func BenchmarkChain(b *testing.B) {
+	logger := T().PrependTime().Prepend("prepend").Append("append").Filter(ALL).FunCall(1, 2)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger(INFO, "hello", "world")
+	}
+}
+
+func BenchmarkFileWritingContext(b *testing.B) {
+	fwc := FileWritingContext{
+		Path:             os.DevNull,
+		CallDelimiter:    "\r\n",
+		ValuesDelimiters: " ",
+	}
+	err := fwc.Init()
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer fwc.Close()
+	logger := fwc.Logger.PrependTime()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger(INFO, "hello", "world")
+	}
+}
+
+func BenchmarkEncoders(b *testing.B) {
+	encoders := map[string]Encoder{
+		"text":   TextEncoder{ValuesDelimiters: " "},
+		"json":   JSONEncoder{},
+		"logfmt": LogfmtEncoder{},
+		"csv":    CSVEncoder{},
+	}
+	names := []string{"isPrime", "isFactor"}
+	for name, encoder := range encoders {
+		// non constant values are boxed by F, not by the typed constructors
+		b.Run(name+"/any", func(b *testing.B) {
+			var buffer bytes.Buffer
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buffer.Reset()
+				encoder.Encode(&buffer, INFO, "is prime", F("n", i+1000), F("name", names[i%len(names)]))
+			}
+		})
+		b.Run(name+"/typed", func(b *testing.B) {
+			var buffer bytes.Buffer
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buffer.Reset()
+				encoder.Encode(&buffer, INFO, "is prime", Int("n", i+1000), String("name", names[i%len(names)]))
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package log4g
+
+import (
+	"path"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// packagePath is the import path of log4g.
+var packagePath = reflect.TypeOf(Field{}).PkgPath()
+
+// CallerFormat configures the capture of callers.
+type CallerFormat struct {
+	// Skip is the number of additional stack frames to skip,
+	// for helpers wrapping the logger.
+	Skip int
+	// FullPath keeps the full package path of the function and the full file path.
+	// By default the function is prefixed by its package name
+	// and the file by its directory name.
+	FullPath bool
+}
+
+// Caller is the location of a call.
+type Caller struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// String formats the caller as file:line.
+func (caller Caller) String() string {
+	return caller.File + ":" + strconv.Itoa(caller.Line)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (caller Caller) MarshalText() ([]byte, error) {
+	return []byte(caller.String()), nil
+}
+
+// newCaller creates a Caller from a stack frame.
+func newCaller(frame runtime.Frame, format CallerFormat) Caller {
+	caller := Caller{
+		Function: frame.Function,
+		File:     frame.File,
+		Line:     frame.Line,
+	}
+	if !format.FullPath {
+		caller.Function = path.Base(caller.Function)
+		caller.File = path.Join(path.Base(path.Dir(caller.File)), path.Base(caller.File))
+	}
+	return caller
+}
+
+// isLog4gFrame tells if the frame is log4g code, tests excluded.
+func isLog4gFrame(frame runtime.Frame) bool {
+	return strings.HasPrefix(frame.Function, packagePath+".") && !strings.HasSuffix(frame.File, "_test.go")
+}
+
+// CaptureCaller returns the caller of the function calling CaptureCaller.
+// format.Skip skips additional frames.
+func CaptureCaller(format CallerFormat) Caller {
+	pcs := make([]uintptr, 1)
+	runtime.Callers(3+format.Skip, pcs)
+	frame, _ := runtime.CallersFrames(pcs).Next()
+	return newCaller(frame, format)
+}
+
+// logCallSite returns the first caller outside of log4g.
+// format.Skip skips additional frames.
+// The PC buffer grows until it holds the call site of deep combinator chains.
+func logCallSite(format CallerFormat) Caller {
+	pcs := make([]uintptr, 32)
+	for {
+		n := runtime.Callers(3, pcs)
+		frames := runtime.CallersFrames(pcs[:n])
+		skip := format.Skip
+		for {
+			frame, more := frames.Next()
+			if !isLog4gFrame(frame) {
+				if skip == 0 {
+					return newCaller(frame, format)
+				}
+				skip--
+			}
+			if !more {
+				if n < len(pcs) {
+					return newCaller(frame, format)
+				}
+				break
+			}
+		}
+		pcs = make([]uintptr, 2*len(pcs))
+	}
+}
+
+// AddCaller prepends a Caller field holding the location of each log call.
+// Frames of log4g, such as the combinators, are skipped.
+func (logger Logger) AddCaller(format CallerFormat) Logger {
+	return func(level string, values ...interface{}) {
+		caller := F(CallerKey, logCallSite(format))
+		logger(level, prependValue(caller, values)...)
+	}
+}
@@ -0,0 +1,91 @@
+package log4g
+
+import (
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// line returns the line of its call.
+func line() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+// shortTestFile is this file path shortened like CallerFormat does.
+func shortTestFile() string {
+	_, file, _, _ := runtime.Caller(0)
+	return path.Join(path.Base(path.Dir(file)), path.Base(file))
+}
+
+// logHelper logs on behalf of its caller.
+func logHelper(logger Logger, msg string) {
+	logger(INFO, msg)
+}
+
+// funCallHelper declares the function call of its caller.
+func funCallHelper(logger Logger) Logger {
+	return logger.FunCallSkip(1, "helped")
+}
+
+func TestAddCaller(t *testing.T) {
+	logger, buffer := NewInMemoryLogger()
+	callerOf := func(i int) Caller {
+		return (*buffer)[i][1].(Field).Value().(Caller)
+	}
+	logger.AddCaller(CallerFormat{})(INFO, "direct")
+	directLine := line() - 1
+	logger.AddCaller(CallerFormat{}).Prepend("combinator").Info("method")
+	methodLine := line() - 1
+	logHelper(logger.AddCaller(CallerFormat{Skip: 1}), "helper")
+	helperLine := line() - 1
+	logger.AddCaller(CallerFormat{FullPath: true})(INFO, "full path")
+	assert.Equal(t, Caller{Function: "log4g.TestAddCaller", File: shortTestFile(), Line: directLine}, callerOf(0))
+	assert.Equal(t, methodLine, callerOf(1).Line)
+	assert.Equal(t, "combinator", (*buffer)[1][2])
+	assert.Equal(t, helperLine, callerOf(2).Line)
+	assert.Equal(t, packagePath+".TestAddCaller", callerOf(3).Function)
+	assert.True(t, strings.HasSuffix(callerOf(3).File, "/caller_test.go"))
+	assert.True(t, len(callerOf(3).File) > len(shortTestFile()))
+	assert.Equal(t, shortTestFile()+":"+itoa(directLine), callerOf(0).String())
+	assert.Equal(t, `{"level":"INFO","caller":"`+shortTestFile()+`:`+itoa(directLine)+`","msg":"direct"}`,
+		EncodeString(JSONEncoder{}, INFO, (*buffer)[0][1:]...))
+}
+
+func TestAddCallerDeepChain(t *testing.T) {
+	logger, buffer := NewInMemoryLogger()
+	deep := logger.AddCaller(CallerFormat{})
+	for i := 0; i < 100; i++ {
+		deep = deep.Prepend(i)
+	}
+	deep(INFO, "deep")
+	deepLine := line() - 1
+	caller := (*buffer)[0][1].(Field).Value().(Caller)
+	assert.Equal(t, Caller{Function: "log4g.TestAddCallerDeepChain", File: shortTestFile(), Line: deepLine}, caller)
+}
+
+func TestCaptureCaller(t *testing.T) {
+	caller := CaptureCaller(CallerFormat{})
+	assert.Equal(t, "testing.tRunner", caller.Function)
+	caller = func() Caller { return CaptureCaller(CallerFormat{Skip: 0}) }()
+	assert.Equal(t, "log4g.TestCaptureCaller", caller.Function)
+}
+
+func TestFunCallSkip(t *testing.T) {
+	logger, buffer := NewInMemoryLogger()
+	funCallHelper(logger)(INFO, "msg")
+	logger.DetailedFunCall("arg")(INFO, "msg")
+	detailedLine := line() - 1
+	assert.Equal(t, " -> TestFunCallSkip [helped] : ", (*buffer)[0][1])
+	detailed := (*buffer)[1][1].(string)
+	assert.True(t, strings.HasPrefix(detailed, " -> TestFunCallSkip [arg] "), detailed)
+	assert.True(t, strings.HasSuffix(detailed, " caller_test.go:"+itoa(detailedLine)+" : "), detailed)
+}
+
+func itoa(i int) string {
+	return strconv.Itoa(i)
+}
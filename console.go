@@ -1,8 +1,11 @@
 package log4g
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 )
 
 // NewConsoleLogger creates a Logger that outputs to console.
@@ -17,3 +20,202 @@ func NewConsoleLogger() Logger {
 		fmt.Fprintf(file, "%s : %v\r\n", level, values)
 	}
 }
+
+// ColorMode tells when the console output is colorised.
+type ColorMode int
+
+const (
+	// ColorAuto colorises terminals unless the NO_COLOR environment variable is set.
+	ColorAuto ColorMode = iota
+	// ColorAlways always colorises.
+	ColorAlways
+	// ColorNever never colorises.
+	ColorNever
+)
+
+// colorReset resets the terminal color.
+const colorReset = "\x1b[0m"
+
+// DefaultColors are the ANSI colors of the logging levels.
+var DefaultColors = map[string]string{
+	FATAL: "\x1b[1;35m",
+	ERROR: "\x1b[31m",
+	WARN:  "\x1b[33m",
+	INFO:  "\x1b[32m",
+	DEBUG: "\x1b[36m",
+	TRACE: "\x1b[90m",
+}
+
+// levelWidth is the width of the level column.
+const levelWidth = len(FATAL)
+
+// ConsoleContext stores the settings for writing logged values to the console.
+type ConsoleContext struct {
+	Logger
+	// Colors maps levels to ANSI escape codes.
+	// Defaults to DefaultColors if field empty.
+	Colors map[string]string
+	Color  ColorMode
+	// Function called to convert a value to string.
+	// Defaults to fmt.Sprint(v) if field empty.
+	FormatingFunc func(value interface{}) string
+	// Value to separate between function calls.
+	// Defaults to "\n" if field empty.
+	CallDelimiter string
+	// Value to separate between function args.
+	// Defaults to " " if field empty.
+	ValuesDelimiters string
+	// Pretty prints fields and multi-line values such as stack traces
+	// indented on the lines following the call.
+	Pretty bool
+	// Encoder replaces the console formatting if not nil.
+	Encoder Encoder
+	// Stdout is the output of the levels missing from Streams.
+	// Defaults to os.Stdout if field empty.
+	Stdout io.Writer
+	// Streams maps levels to their output.
+	// Defaults to every level written to Stdout if field empty,
+	// or to FATAL and ERROR written to os.Stderr if Stdout is empty too.
+	Streams map[string]io.Writer
+}
+
+// isTerminal tells if w is a terminal.
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorised tells if the output to w is colorised.
+func (cc *ConsoleContext) colorised(w io.Writer) bool {
+	switch cc.Color {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	return os.Getenv("NO_COLOR") == "" && isTerminal(w)
+}
+
+// format converts a value to string.
+func (cc *ConsoleContext) format(value interface{}) string {
+	if cc.FormatingFunc == nil {
+		return fmt.Sprint(value)
+	}
+	return cc.FormatingFunc(value)
+}
+
+// formatValues format the logger values into the console output.
+func (cc *ConsoleContext) formatValues(colorised bool, level string, values ...interface{}) string {
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+	cc.encode(buffer, colorised, level, values...)
+	return buffer.String()
+}
+
+// encode writes the logger values to the buffer.
+func (cc *ConsoleContext) encode(buffer *bytes.Buffer, colorised bool, level string, values ...interface{}) {
+	if cc.Encoder != nil {
+		cc.Encoder.Encode(buffer, level, values...)
+		buffer.WriteString(cc.CallDelimiter)
+		return
+	}
+	color, hasColor := cc.Colors[level]
+	if colorised && hasColor {
+		buffer.WriteString(color)
+	}
+	buffer.WriteString(level)
+	if colorised && hasColor {
+		buffer.WriteString(colorReset)
+	}
+	for i := len(level); i < levelWidth; i++ {
+		buffer.WriteByte(' ')
+	}
+	buffer.WriteString(" :")
+	multiLines := make([]string, 0)
+	for _, value := range values {
+		formatted := cc.format(value)
+		if _, isField := value.(Field); cc.Pretty && isField {
+			multiLines = append(multiLines, formatted)
+			continue
+		}
+		if cc.Pretty && strings.Contains(formatted, "\n") {
+			multiLines = append(multiLines, formatted)
+			continue
+		}
+		buffer.WriteString(cc.ValuesDelimiters)
+		buffer.WriteString(formatted)
+	}
+	for _, multiLine := range multiLines {
+		for _, line := range strings.Split(strings.TrimRight(multiLine, "\r\n"), "\n") {
+			buffer.WriteString(cc.CallDelimiter)
+			buffer.WriteString("    ")
+			buffer.WriteString(strings.TrimRight(line, "\r"))
+		}
+	}
+	buffer.WriteString(cc.CallDelimiter)
+}
+
+// Init initialises the console output.
+func (cc *ConsoleContext) Init() {
+	if cc.Colors == nil {
+		cc.Colors = DefaultColors
+	}
+	if cc.CallDelimiter == "" {
+		cc.CallDelimiter = "\n"
+	}
+	if cc.ValuesDelimiters == "" {
+		cc.ValuesDelimiters = " "
+	}
+	if cc.Streams == nil && cc.Stdout == nil {
+		cc.Streams = map[string]io.Writer{FATAL: os.Stderr, ERROR: os.Stderr}
+	}
+	if cc.Streams == nil {
+		cc.Streams = map[string]io.Writer{}
+	}
+	if cc.Stdout == nil {
+		cc.Stdout = os.Stdout
+	}
+	stdoutColorised := cc.colorised(cc.Stdout)
+	colorisedStreams := make(map[string]bool, len(cc.Streams))
+	for level, stream := range cc.Streams {
+		colorisedStreams[level] = cc.colorised(stream)
+	}
+	cc.Logger = func(level string, values ...interface{}) {
+		stream, ok := cc.Streams[level]
+		if !ok {
+			stream = cc.Stdout
+		}
+		colorised, ok := colorisedStreams[level]
+		if !ok {
+			colorised = stdoutColorised
+		}
+		buffer := getBuffer()
+		defer putBuffer(buffer)
+		cc.encode(buffer, colorised, level, values...)
+		_, err := stream.Write(buffer.Bytes())
+		if err != nil {
+			panic(err)
+		}
+	}
+	// adds a lock for keeping lines from interleaving.
+	cc.Logger = cc.Logger.WithLock()
+}
+
+// NewWriterLogger creates a Logger that outputs to stdout,
+// or to the stream of the level in streams.
+// Every level is written to stdout if streams is nil.
+func NewWriterLogger(stdout io.Writer, streams map[string]io.Writer) Logger {
+	cc := ConsoleContext{
+		Stdout:  stdout,
+		Streams: streams,
+	}
+	cc.Init()
+	return cc.Logger
+}
@@ -3,8 +3,10 @@ package log4g
 import (
 	"bytes"
 	"io"
+	"io/ioutil"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -64,3 +66,93 @@ func TestConsoleLogger(t *testing.T) {
 		}
 	})
 }
+
+func TestConsoleContext(t *testing.T) {
+	cc := ConsoleContext{}
+	cc.Init()
+	t.Run("format", func(t *testing.T) {
+		assert.Equal(t, "[INFO]  : I AM 1\n", cc.formatValues(false, INFO, "I", "AM", 1))
+		assert.Equal(t, "topic   : I AM 1\n", cc.formatValues(false, "topic", "I", "AM", 1))
+		assert.Equal(t, "\x1b[33m[WARN] \x1b[0m : I AM 1\n", cc.formatValues(true, WARN, "I", "AM", 1))
+		assert.Equal(t, "[ALL]   : I AM 1\n", cc.formatValues(true, ALL, "I", "AM", 1))
+	})
+	t.Run("pretty", func(t *testing.T) {
+		cc := ConsoleContext{Pretty: true, CallDelimiter: "\r\n"}
+		cc.Init()
+		assert.Equal(t, "[ERROR] : panic\r\n    goroutine 1\r\n    main.go:12\r\n",
+			cc.formatValues(false, ERROR, "panic", "goroutine 1\nmain.go:12\n"))
+		assert.Equal(t, "[INFO]  : is prime\r\n    n=41\r\n",
+			cc.formatValues(false, INFO, "is prime", F("n", 41)))
+	})
+	t.Run("encoder", func(t *testing.T) {
+		cc := ConsoleContext{Encoder: LogfmtEncoder{}}
+		cc.Init()
+		assert.Equal(t, "level=INFO msg=\"is prime\" n=41\n", cc.formatValues(true, INFO, "is prime", F("n", 41)))
+	})
+	t.Run("colorised", func(t *testing.T) {
+		var buffer bytes.Buffer
+		assert.False(t, (&ConsoleContext{}).colorised(&buffer))
+		assert.True(t, (&ConsoleContext{Color: ColorAlways}).colorised(&buffer))
+		assert.False(t, (&ConsoleContext{Color: ColorNever}).colorised(os.Stdout))
+		os.Setenv("NO_COLOR", "1")
+		defer os.Unsetenv("NO_COLOR")
+		assert.False(t, (&ConsoleContext{}).colorised(os.Stdout))
+	})
+	t.Run("streams", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		logger := NewWriterLogger(&stdout, map[string]io.Writer{
+			FATAL: &stderr,
+			ERROR: &stderr,
+			WARN:  &stderr,
+		})
+		logger(INFO, "I", "AM", "FIRST")
+		logger(WARN, "I", "AM", "SECOND")
+		logger(ERROR, "I", "AM", "THIRD")
+		assert.Equal(t, "[INFO]  : I AM FIRST\n", stdout.String())
+		assert.Equal(t, "[WARN]  : I AM SECOND\n[ERROR] : I AM THIRD\n", stderr.String())
+	})
+	t.Run("nil streams", func(t *testing.T) {
+		r, w, _ := os.Pipe()
+		stderr := os.Stderr
+		os.Stderr = w
+		var output bytes.Buffer
+		logger := NewWriterLogger(&output, nil)
+		logger(ERROR, "error")
+		logger(FATAL, "fatal")
+		os.Stderr = stderr
+		w.Close()
+		leaked, _ := ioutil.ReadAll(r)
+		r.Close()
+		assert.Equal(t, "", string(leaked))
+		assert.Equal(t, "[ERROR] : error\n[FATAL] : fatal\n", output.String())
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		var output bytes.Buffer
+		logger := NewWriterLogger(&output, map[string]io.Writer{ERROR: &output})
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if i%2 == 0 {
+					logger(ERROR, "line", i)
+				} else {
+					logger(INFO, "line", i)
+				}
+			}(i)
+		}
+		wg.Wait()
+		lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+		assert.Equal(t, 100, len(lines))
+		for _, line := range lines {
+			assert.Regexp(t, `^\[(INFO\] |ERROR\]) : line \d+$`, line)
+		}
+	})
+	t.Run("failing writer", func(t *testing.T) {
+		r, w, _ := os.Pipe()
+		r.Close()
+		err := NewWriterLogger(w, nil).NoPanic(INFO, "lost")
+		assert.NotNil(t, err)
+		w.Close()
+	})
+}
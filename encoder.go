@@ -0,0 +1,395 @@
+package log4g
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Encoder encodes the values of a logger call.
+// The call delimiter is written by the sink.
+type Encoder interface {
+	Encode(buffer *bytes.Buffer, level string, values ...interface{})
+}
+
+// Decoder parses an entry written by an Encoder back into a level and values.
+// TextEncoder, JSONEncoder, LogfmtEncoder and CSVEncoder are Decoders.
+type Decoder interface {
+	Decode(entry string) (level string, values []interface{}, err error)
+}
+
+// maxPooledBufferSize is the capacity above which buffers aren't pooled.
+const maxPooledBufferSize = 64 << 10
+
+// bufferPool stores the buffers used for encoding.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	buffer := bufferPool.Get().(*bytes.Buffer)
+	buffer.Reset()
+	return buffer
+}
+
+// putBuffer returns the buffer to the pool.
+func putBuffer(buffer *bytes.Buffer) {
+	if buffer.Cap() > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(buffer)
+}
+
+// EncodeString encodes a logger call to string.
+func EncodeString(encoder Encoder, level string, values ...interface{}) string {
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+	encoder.Encode(buffer, level, values...)
+	return buffer.String()
+}
+
+// TextEncoder encodes the level followed by the values separated by ValuesDelimiters.
+type TextEncoder struct {
+	// Function called to convert a value to string.
+	// Defaults to fmt.Sprint(v) if field empty.
+	FormatingFunc    func(value interface{}) string
+	ValuesDelimiters string
+}
+
+// Encode implements Encoder.
+func (enc TextEncoder) Encode(buffer *bytes.Buffer, level string, values ...interface{}) {
+	buffer.WriteString(level)
+	for _, value := range values {
+		buffer.WriteString(enc.ValuesDelimiters)
+		if enc.FormatingFunc == nil {
+			writeText(buffer, value)
+		} else {
+			buffer.WriteString(enc.FormatingFunc(value))
+		}
+	}
+}
+
+// Decode implements Decoder.
+// Known levels are matched first so their padding isn't taken for a delimiter,
+// the values are returned as strings.
+func (enc TextEncoder) Decode(entry string) (string, []interface{}, error) {
+	level, rest, hasValues := entry, "", false
+	for _, known := range levels {
+		if len(entry) > len(known) && strings.HasPrefix(entry, known+enc.ValuesDelimiters) {
+			level, rest, hasValues = known, entry[len(known)+len(enc.ValuesDelimiters):], true
+			break
+		}
+	}
+	if !hasValues && enc.ValuesDelimiters != "" {
+		if i := strings.Index(entry, enc.ValuesDelimiters); i >= 0 {
+			level, rest, hasValues = entry[:i], entry[i+len(enc.ValuesDelimiters):], true
+		}
+	}
+	values := make([]interface{}, 0)
+	if !hasValues {
+		return level, values, nil
+	}
+	if enc.ValuesDelimiters == "" {
+		return level, append(values, rest), nil
+	}
+	for _, value := range strings.Split(rest, enc.ValuesDelimiters) {
+		values = append(values, value)
+	}
+	return level, values, nil
+}
+
+// writeText writes a value formatted like fmt.Sprint(value).
+func writeText(buffer *bytes.Buffer, value interface{}) {
+	switch value.(type) {
+	case string, int, int64, bool, Field:
+		writeValue(buffer, value, "")
+	default:
+		fmt.Fprint(buffer, value)
+	}
+}
+
+// JSONEncoder encodes logger calls as JSON objects.
+//
+//	{"level":"INFO","time":"2018-11-29T00:38:11Z","msg":"square root 6","n":41}
+//
+// The values that aren't fields are joined in the msg key.
+type JSONEncoder struct {
+	// Layout of the time values.
+	// Defaults to time.RFC3339Nano if field empty.
+	TimeLayout string
+}
+
+// Encode implements Encoder.
+func (enc JSONEncoder) Encode(buffer *bytes.Buffer, level string, values ...interface{}) {
+	buffer.WriteByte('{')
+	writeJSONString(buffer, LevelKey)
+	buffer.WriteByte(':')
+	writeJSONString(buffer, LevelName(level))
+	writeField := func(field Field) {
+		buffer.WriteByte(',')
+		writeJSONString(buffer, field.Key)
+		buffer.WriteByte(':')
+		enc.writeField(buffer, field)
+	}
+	eachField(values, func(field Field) {
+		if isHeader(field) {
+			writeField(field)
+		}
+	})
+	if hasMessage(values) {
+		scratch := getBuffer()
+		defer putBuffer(scratch)
+		writeMessage(scratch, values, enc.TimeLayout)
+		buffer.WriteByte(',')
+		writeJSONString(buffer, MessageKey)
+		buffer.WriteByte(':')
+		writeJSONBytes(buffer, scratch.Bytes())
+	}
+	eachField(values, func(field Field) {
+		if !isHeader(field) {
+			writeField(field)
+		}
+	})
+	buffer.WriteByte('}')
+}
+
+// writeField writes the value of a field as JSON.
+// Values that can't be marshalled are written as strings.
+func (enc JSONEncoder) writeField(buffer *bytes.Buffer, field Field) {
+	switch field.fieldType {
+	case stringField:
+		writeJSONString(buffer, field.str)
+		return
+	case intField, int64Field, boolField:
+		field.writeText(buffer, "")
+		return
+	case floatField:
+		value := math.Float64frombits(uint64(field.integer))
+		if !math.IsInf(value, 0) && !math.IsNaN(value) {
+			field.writeText(buffer, "")
+			return
+		}
+	case durationField:
+		writeJSONString(buffer, time.Duration(field.integer).String())
+		return
+	}
+	switch value := field.value.(type) {
+	case time.Time:
+		scratch := getBuffer()
+		defer putBuffer(scratch)
+		writeValue(scratch, value, enc.TimeLayout)
+		writeJSONBytes(buffer, scratch.Bytes())
+		return
+	case error:
+		writeJSONString(buffer, value.Error())
+		return
+	}
+	byts, err := json.Marshal(field.Value())
+	if err != nil {
+		writeJSONString(buffer, fmt.Sprint(field.Value()))
+		return
+	}
+	buffer.Write(byts)
+}
+
+// Decode implements Decoder.
+// The level is parsed with ParseLevel and kept as is if unknown.
+// The msg value is returned as a string, the other keys as fields of JSON values,
+// integers as int.
+func (enc JSONEncoder) Decode(entry string) (string, []interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(entry))
+	decoder.UseNumber()
+	token, err := decoder.Token()
+	if err != nil {
+		return "", nil, err
+	}
+	if token != json.Delim('{') {
+		return "", nil, fmt.Errorf("JSON entry isn't an object: %q", entry)
+	}
+	var level string
+	values := make([]interface{}, 0)
+	for decoder.More() {
+		token, err = decoder.Token()
+		if err != nil {
+			return "", nil, err
+		}
+		key, _ := token.(string)
+		var value interface{}
+		err = decoder.Decode(&value)
+		if err != nil {
+			return "", nil, err
+		}
+		if number, ok := value.(json.Number); ok {
+			if i, err := strconv.Atoi(number.String()); err == nil {
+				value = i
+			} else {
+				value, _ = number.Float64()
+			}
+		}
+		switch key {
+		case LevelKey:
+			level = fmt.Sprint(value)
+			if parsedLevel, err := ParseLevel(level); err == nil {
+				level = parsedLevel
+			}
+		case MessageKey:
+			values = append(values, fmt.Sprint(value))
+		default:
+			values = append(values, F(key, value))
+		}
+	}
+	// depending on the Go version, More is false at the end of a truncated entry
+	token, err = decoder.Token()
+	if err != nil || token != json.Delim('}') {
+		return "", nil, fmt.Errorf("truncated JSON entry: %q", entry)
+	}
+	if _, err = decoder.Token(); err != io.EOF {
+		return "", nil, fmt.Errorf("trailing data after JSON entry: %q", entry)
+	}
+	return level, values, nil
+}
+
+// writeJSONString writes s as a JSON string.
+func writeJSONString(buffer *bytes.Buffer, s string) {
+	buffer.WriteByte('"')
+	for i := 0; i < len(s); {
+		if s[i] < utf8.RuneSelf {
+			writeJSONByte(buffer, s[i])
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buffer.WriteString(`\ufffd`)
+		} else {
+			buffer.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	buffer.WriteByte('"')
+}
+
+// writeJSONBytes writes b as a JSON string.
+func writeJSONBytes(buffer *bytes.Buffer, b []byte) {
+	buffer.WriteByte('"')
+	for i := 0; i < len(b); {
+		if b[i] < utf8.RuneSelf {
+			writeJSONByte(buffer, b[i])
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(b[i:])
+		if r == utf8.RuneError && size == 1 {
+			buffer.WriteString(`\ufffd`)
+		} else {
+			buffer.Write(b[i : i+size])
+		}
+		i += size
+	}
+	buffer.WriteByte('"')
+}
+
+// writeJSONByte writes an ASCII character escaped for JSON strings.
+func writeJSONByte(buffer *bytes.Buffer, c byte) {
+	const hex = "0123456789abcdef"
+	switch c {
+	case '"', '\\':
+		buffer.WriteByte('\\')
+		buffer.WriteByte(c)
+	case '\n':
+		buffer.WriteString(`\n`)
+	case '\r':
+		buffer.WriteString(`\r`)
+	case '\t':
+		buffer.WriteString(`\t`)
+	default:
+		if c < 0x20 {
+			buffer.WriteString(`\u00`)
+			buffer.WriteByte(hex[c>>4])
+			buffer.WriteByte(hex[c&0xf])
+		} else {
+			buffer.WriteByte(c)
+		}
+	}
+}
+
+// CSVEncoder encodes the level followed by the values as RFC 4180 columns.
+// Fields are written as key=value columns.
+type CSVEncoder struct {
+	// Comma is the column delimiter.
+	// Defaults to ',' if field empty.
+	Comma rune
+	// Function called to convert a value to string.
+	// Defaults to fmt.Sprint(v) if field empty.
+	FormatingFunc func(value interface{}) string
+}
+
+// Encode implements Encoder.
+func (enc CSVEncoder) Encode(buffer *bytes.Buffer, level string, values ...interface{}) {
+	comma := enc.Comma
+	if comma == 0 {
+		comma = ','
+	}
+	scratch := getBuffer()
+	defer putBuffer(scratch)
+	scratch.WriteString(level)
+	enc.writeColumn(buffer, comma, scratch.Bytes())
+	for _, value := range values {
+		buffer.WriteRune(comma)
+		scratch.Reset()
+		if enc.FormatingFunc == nil {
+			writeText(scratch, value)
+		} else {
+			scratch.WriteString(enc.FormatingFunc(value))
+		}
+		enc.writeColumn(buffer, comma, scratch.Bytes())
+	}
+}
+
+// writeColumn writes a column quoting it if needed.
+func (enc CSVEncoder) writeColumn(buffer *bytes.Buffer, comma rune, column []byte) {
+	if len(column) == 0 || column[0] != ' ' && bytes.IndexRune(column, comma) < 0 && bytes.IndexAny(column, "\"\r\n") < 0 {
+		buffer.Write(column)
+		return
+	}
+	buffer.WriteByte('"')
+	for _, c := range column {
+		if c == '"' {
+			buffer.WriteByte('"')
+		}
+		buffer.WriteByte(c)
+	}
+	buffer.WriteByte('"')
+}
+
+// Decode implements Decoder.
+// The values are returned as strings, fields included.
+func (enc CSVEncoder) Decode(entry string) (string, []interface{}, error) {
+	reader := csv.NewReader(strings.NewReader(entry))
+	reader.Comma = enc.Comma
+	if reader.Comma == 0 {
+		reader.Comma = ','
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	columns, err := reader.Read()
+	if err != nil {
+		return "", nil, err
+	}
+	values := make([]interface{}, len(columns)-1)
+	for i, column := range columns[1:] {
+		values[i] = column
+	}
+	return columns[0], values, nil
+}
@@ -0,0 +1,98 @@
+package log4g
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextEncoder(t *testing.T) {
+	fwc := FileWritingContext{ValuesDelimiters: " | ", CallDelimiter: "\r\n"}
+	encoder := TextEncoder{ValuesDelimiters: " | "}
+	assert.Equal(t, "hello | world | 1\r\n", fwc.FormatValues("hello", "world", 1))
+	assert.Equal(t, "hello | world | 1", EncodeString(encoder, "hello", "world", 1))
+	encoder.FormatingFunc = func(v interface{}) string { return "*" }
+	assert.Equal(t, "hello | * | *", EncodeString(encoder, "hello", "world", 1))
+}
+
+func TestTextEncoderDecode(t *testing.T) {
+	encoder := TextEncoder{ValuesDelimiters: " | "}
+	decode := func(entry string) []interface{} {
+		level, values, err := encoder.Decode(entry)
+		assert.Nil(t, err)
+		return prependValue(level, values)
+	}
+	assert.Equal(t, []interface{}{"hello", "world", "1"}, decode("hello | world | 1"))
+	assert.Equal(t, []interface{}{INFO, "multi\nline", ""}, decode(EncodeString(encoder, INFO, "multi\nline", "")))
+	assert.Equal(t, []interface{}{INFO}, decode(INFO))
+	assert.Equal(t, []interface{}{"topic"}, decode("topic"))
+	encoder.ValuesDelimiters = ""
+	assert.Equal(t, []interface{}{INFO, "is prime"}, decode(INFO+"is prime"))
+}
+
+func TestJSONEncoder(t *testing.T) {
+	date := time.Date(2018, 11, 29, 0, 38, 11, 0, time.UTC)
+	line := EncodeString(JSONEncoder{}, INFO, "square root", F("n", 41), 6, F(TimeKey, date),
+		F("quoted", "a \"quoted\"\n\x01value\xff"), F("err", errors.New("file not found")),
+		F("elapsed", time.Second), F("list", []int{1, 2}), F("func", func() {}))
+	assert.True(t, strings.HasPrefix(line, `{"level":"INFO","time":"2018-11-29T00:38:11Z","msg":"square root 6","n":41,`+
+		`"quoted":"a \"quoted\"\n\u0001value\ufffd","err":"file not found","elapsed":"1s","list":[1,2],"func":"0x`), line)
+	var decoded map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(line), &decoded))
+	assert.Equal(t, "a \"quoted\"\n\x01value�", decoded["quoted"])
+	assert.Equal(t, `{"level":"WARN","time":"12:38AM"}`, EncodeString(JSONEncoder{TimeLayout: time.Kitchen}, WARN, F(TimeKey, date)))
+}
+
+func TestJSONEncoderDecode(t *testing.T) {
+	level, values, err := JSONEncoder{}.Decode(`{"level":"INFO","time":"2018-11-29T00:38:11Z","msg":"square root 6","n":41,"ratio":0.5,"list":[1,2],"ok":true}`)
+	assert.Nil(t, err)
+	assert.Equal(t, INFO, level)
+	assert.Equal(t, []interface{}{
+		F(TimeKey, "2018-11-29T00:38:11Z"),
+		"square root 6",
+		F("n", 41),
+		F("ratio", 0.5),
+		F("list", []interface{}{json.Number("1"), json.Number("2")}),
+		F("ok", true),
+	}, values)
+	level, _, err = JSONEncoder{}.Decode(`{"level":"topic"}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "topic", level)
+	_, _, err = JSONEncoder{}.Decode(`[1]`)
+	assert.NotNil(t, err)
+	_, _, err = JSONEncoder{}.Decode(`{"level":`)
+	assert.NotNil(t, err)
+	_, _, err = JSONEncoder{}.Decode(`{"level":"INFO","s":"x"`)
+	assert.NotNil(t, err)
+	_, _, err = JSONEncoder{}.Decode(`{"level":"INFO"}{"n":41}`)
+	assert.EqualError(t, err, `trailing data after JSON entry: "{\"level\":\"INFO\"}{\"n\":41}"`)
+}
+
+func TestCSVEncoder(t *testing.T) {
+	assert.Equal(t, `[INFO] ,is prime,41,n=41`, EncodeString(CSVEncoder{}, INFO, "is prime", 41, F("n", 41)))
+	assert.Equal(t, `topic,"a,b","say ""hi""","multi`+"\n"+`line",," space"`, EncodeString(CSVEncoder{}, "topic", "a,b", `say "hi"`, "multi\nline", "", " space"))
+	assert.Equal(t, `topic;a,b`, EncodeString(CSVEncoder{Comma: ';'}, "topic", "a,b"))
+}
+
+func TestCSVEncoderDecode(t *testing.T) {
+	level, values, err := CSVEncoder{}.Decode(`topic,"a,b","say ""hi""","multi` + "\n" + `line",," space"`)
+	assert.Nil(t, err)
+	assert.Equal(t, "topic", level)
+	assert.Equal(t, []interface{}{"a,b", `say "hi"`, "multi\nline", "", " space"}, values)
+	level, values, err = CSVEncoder{Comma: ';'}.Decode(`topic;a,b`)
+	assert.Nil(t, err)
+	assert.Equal(t, "topic", level)
+	assert.Equal(t, []interface{}{"a,b"}, values)
+}
+
+func TestInMemoryLogsEncode(t *testing.T) {
+	logger, buffer := NewInMemoryLogger()
+	logger(INFO, "is prime", F("n", 41))
+	logger(DEBUG, "square root", 6)
+	assert.Equal(t, []string{`level=INFO msg="is prime" n=41`, `level=DEBUG msg="square root 6"`}, buffer.Encode(LogfmtEncoder{}))
+	assert.Equal(t, []string{`{"level":"INFO","msg":"is prime","n":41}`, `{"level":"DEBUG","msg":"square root 6"}`}, buffer.Encode(JSONEncoder{}))
+}
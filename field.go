@@ -0,0 +1,260 @@
+package log4g
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	// LevelKey is the key of the level in structured encodings.
+	LevelKey = "level"
+	// TimeKey is the key of the time field.
+	TimeKey = "time"
+	// CallerKey is the key of the caller field.
+	CallerKey = "caller"
+	// MessageKey is the key of the values that aren't fields in structured encodings.
+	MessageKey = "msg"
+	// ErrorKey is the key of the Err field.
+	ErrorKey = "error"
+)
+
+// fieldType tells how the value of a field is stored.
+type fieldType uint8
+
+const (
+	anyField fieldType = iota
+	stringField
+	intField
+	int64Field
+	boolField
+	floatField
+	durationField
+)
+
+// Field is a named value logged alongside the other values.
+// Strings and numbers are stored without being boxed in an interface{},
+// the Field itself is boxed once when passed to a Logger.
+type Field struct {
+	Key       string
+	fieldType fieldType
+	integer   int64
+	str       string
+	value     interface{}
+}
+
+// F creates a Field.
+// The value is stored as the matching typed field when possible.
+func F(key string, value interface{}) Field {
+	switch value := value.(type) {
+	case string:
+		return String(key, value)
+	case int:
+		return Int(key, value)
+	case int64:
+		return Int64(key, value)
+	case bool:
+		return Bool(key, value)
+	case float64:
+		return Float64(key, value)
+	case time.Duration:
+		return Duration(key, value)
+	}
+	return Field{Key: key, value: value}
+}
+
+// String creates a Field holding a string.
+func String(key string, value string) Field {
+	return Field{Key: key, fieldType: stringField, str: value}
+}
+
+// Int creates a Field holding an int.
+func Int(key string, value int) Field {
+	return Field{Key: key, fieldType: intField, integer: int64(value)}
+}
+
+// Int64 creates a Field holding an int64.
+func Int64(key string, value int64) Field {
+	return Field{Key: key, fieldType: int64Field, integer: value}
+}
+
+// Bool creates a Field holding a bool.
+func Bool(key string, value bool) Field {
+	field := Field{Key: key, fieldType: boolField}
+	if value {
+		field.integer = 1
+	}
+	return field
+}
+
+// Float64 creates a Field holding a float64.
+func Float64(key string, value float64) Field {
+	return Field{Key: key, fieldType: floatField, integer: int64(math.Float64bits(value))}
+}
+
+// Duration creates a Field holding a time.Duration.
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, fieldType: durationField, integer: int64(value)}
+}
+
+// Time creates a Field holding a time.Time.
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, value: value}
+}
+
+// Err creates a Field holding an error with the ErrorKey.
+func Err(err error) Field {
+	return Field{Key: ErrorKey, value: err}
+}
+
+// Value returns the value of the field.
+func (field Field) Value() interface{} {
+	switch field.fieldType {
+	case stringField:
+		return field.str
+	case intField:
+		return int(field.integer)
+	case int64Field:
+		return field.integer
+	case boolField:
+		return field.integer == 1
+	case floatField:
+		return math.Float64frombits(uint64(field.integer))
+	case durationField:
+		return time.Duration(field.integer)
+	}
+	return field.value
+}
+
+// String formats the field as key=value.
+func (field Field) String() string {
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+	buffer.WriteString(field.Key)
+	buffer.WriteByte('=')
+	field.writeText(buffer, "")
+	return buffer.String()
+}
+
+// writeText writes the value of the field as text.
+// Times are formatted with layout, defaulting to time.RFC3339Nano.
+func (field Field) writeText(buffer *bytes.Buffer, layout string) {
+	var scratch [32]byte
+	switch field.fieldType {
+	case stringField:
+		buffer.WriteString(field.str)
+	case intField, int64Field:
+		buffer.Write(strconv.AppendInt(scratch[:0], field.integer, 10))
+	case boolField:
+		buffer.Write(strconv.AppendBool(scratch[:0], field.integer == 1))
+	case floatField:
+		buffer.Write(strconv.AppendFloat(scratch[:0], math.Float64frombits(uint64(field.integer)), 'g', -1, 64))
+	case durationField:
+		buffer.WriteString(time.Duration(field.integer).String())
+	default:
+		writeValue(buffer, field.value, layout)
+	}
+}
+
+// writeValue writes a value as text for structured encoders.
+// Times are formatted with layout, defaulting to time.RFC3339Nano.
+func writeValue(buffer *bytes.Buffer, value interface{}, layout string) {
+	var scratch [64]byte
+	switch value := value.(type) {
+	case nil:
+	case string:
+		buffer.WriteString(value)
+	case int:
+		buffer.Write(strconv.AppendInt(scratch[:0], int64(value), 10))
+	case int64:
+		buffer.Write(strconv.AppendInt(scratch[:0], value, 10))
+	case bool:
+		buffer.Write(strconv.AppendBool(scratch[:0], value))
+	case Field:
+		buffer.WriteString(value.Key)
+		buffer.WriteByte('=')
+		value.writeText(buffer, layout)
+	case time.Time:
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+		buffer.Write(value.AppendFormat(scratch[:0], layout))
+	default:
+		fmt.Fprint(buffer, value)
+	}
+}
+
+// headerKeys are the keys of the fields encoded before the message, in order.
+var headerKeys = [...]string{TimeKey, CallerKey}
+
+// isHeader tells if the field is encoded before the message.
+func isHeader(field Field) bool {
+	return field.Key == TimeKey || field.Key == CallerKey
+}
+
+// hasMessage tells if some values aren't fields.
+func hasMessage(values []interface{}) bool {
+	for _, value := range values {
+		if _, ok := value.(Field); !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasField tells if some values are fields.
+func hasField(values []interface{}) bool {
+	for _, value := range values {
+		if _, ok := value.(Field); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// writeMessage writes the values that aren't fields separated by spaces.
+func writeMessage(buffer *bytes.Buffer, values []interface{}, layout string) {
+	first := true
+	for _, value := range values {
+		if _, ok := value.(Field); ok {
+			continue
+		}
+		if !first {
+			buffer.WriteByte(' ')
+		}
+		first = false
+		writeValue(buffer, value, layout)
+	}
+}
+
+// eachField calls f on the fields of values, the header fields first.
+func eachField(values []interface{}, f func(field Field)) {
+	for _, headerKey := range headerKeys {
+		for _, value := range values {
+			if field, ok := value.(Field); ok && field.Key == headerKey {
+				f(field)
+			}
+		}
+	}
+	for _, value := range values {
+		if field, ok := value.(Field); ok && !isHeader(field) {
+			f(field)
+		}
+	}
+}
+
+// splitFields separates the fields from the other values.
+// The header fields are moved in front of the other fields.
+func splitFields(values []interface{}) (message []interface{}, fields []Field) {
+	for _, value := range values {
+		if _, ok := value.(Field); !ok {
+			message = append(message, value)
+		}
+	}
+	eachField(values, func(field Field) {
+		fields = append(fields, field)
+	})
+	return message, fields
+}
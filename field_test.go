@@ -0,0 +1,37 @@
+package log4g
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestField(t *testing.T) {
+	date := time.Date(2018, 11, 29, 0, 38, 11, 0, time.UTC)
+	err := errors.New("file not found")
+	fields := []struct {
+		field    Field
+		value    interface{}
+		expected string
+	}{
+		{String("s", "hello"), "hello", "s=hello"},
+		{Int("i", -41), -41, "i=-41"},
+		{Int64("i", 41), int64(41), "i=41"},
+		{Bool("b", true), true, "b=true"},
+		{Float64("f", 0.5), 0.5, "f=0.5"},
+		{Duration("d", time.Second), time.Second, "d=1s"},
+		{Time("t", date), date, "t=2018-11-29T00:38:11Z"},
+		{Err(err), err, "error=file not found"},
+		{F("any", []int{1}), []int{1}, "any=[1]"},
+		{F("nil", nil), nil, "nil="},
+	}
+	for _, field := range fields {
+		assert.Equal(t, field.value, field.field.Value())
+		assert.Equal(t, field.expected, field.field.String())
+	}
+	assert.Equal(t, Int("n", 41), F("n", 41))
+	assert.Equal(t, Int64("n", 41), F("n", int64(41)))
+	assert.Equal(t, String("s", "hello"), F("s", "hello"))
+}
@@ -2,7 +2,6 @@ package log4g
 
 import (
 	"bufio"
-	"bytes"
 	"fmt"
 	"os"
 	"sync"
@@ -17,22 +16,24 @@ type FileWritingContext struct {
 	CallDelimiter    string
 	ValuesDelimiters string
 	Path             string
+	// Encoder replaces FormatingFunc and ValuesDelimiters if not nil.
+	Encoder Encoder
+}
+
+// encoder returns the Encoder, defaults to a TextEncoder.
+func (fwc FileWritingContext) encoder() Encoder {
+	if fwc.Encoder != nil {
+		return fwc.Encoder
+	}
+	return TextEncoder{
+		FormatingFunc:    fwc.FormatingFunc,
+		ValuesDelimiters: fwc.ValuesDelimiters,
+	}
 }
 
 // FormatValues format the logger values into a line to write on the log file
 func (fwc FileWritingContext) FormatValues(level string, values ...interface{}) string {
-	var buffer bytes.Buffer
-	buffer.WriteString(level)
-	for _, value := range values {
-		buffer.WriteString(fwc.ValuesDelimiters)
-		if fwc.FormatingFunc == nil {
-			buffer.WriteString(fmt.Sprint(value))
-		} else {
-			buffer.WriteString(fwc.FormatingFunc(value))
-		}
-	}
-	buffer.WriteString(fwc.CallDelimiter)
-	return buffer.String()
+	return EncodeString(fwc.encoder(), level, values...) + fwc.CallDelimiter
 }
 
 // Close the underlying file.
@@ -55,9 +56,13 @@ func (fwc *FileWritingContext) Init() error {
 	}
 	fwc.File = file
 	fwc.writer = bufio.NewWriter(file)
+	encoder := fwc.encoder()
 	fwc.Logger = func(level string, values ...interface{}) {
-		byts := fwc.FormatValues(level, values...)
-		_, err := fwc.writer.WriteString(byts)
+		buffer := getBuffer()
+		defer putBuffer(buffer)
+		encoder.Encode(buffer, level, values...)
+		buffer.WriteString(fwc.CallDelimiter)
+		_, err := fwc.writer.Write(buffer.Bytes())
 		if err != nil {
 			panic(err)
 		}
@@ -71,20 +76,29 @@ func (fwc *FileWritingContext) Init() error {
 	return nil
 }
 
+// Health reports whether the file is open.
+func (fwc *FileWritingContext) Health() SinkHealth {
+	if fwc.File == nil {
+		return SinkHealth{}
+	}
+	return SinkHealth{OpenFiles: 1}
+}
+
 // DirLogger is a struct for keeping that of files open in the same folder.
 type DirLogger struct {
 	DirContext FileWritingContext
 	OpenFiles  []FileWritingContext
+	lock       sync.Mutex
 }
 
 // topicToPath convert a topic to a file path.
-func (dirLogger DirLogger) topicToPath(topic string) string {
+func (dirLogger *DirLogger) topicToPath(topic string) string {
 	return fmt.Sprintf("%s/%s", dirLogger.DirContext.Path, topic)
 }
 
 // find returns the open stream for the file.
 // returns nil on file not open.
-func (dirLogger DirLogger) find(topic string) *FileWritingContext {
+func (dirLogger *DirLogger) find(topic string) *FileWritingContext {
 	for _, openFile := range dirLogger.OpenFiles {
 		if openFile.Path == dirLogger.topicToPath(topic) {
 			return &openFile
@@ -112,6 +126,8 @@ func (dirLogger *DirLogger) Get(topic string) *FileWritingContext {
 // Close the Directory files.
 // Can panic.
 func (dirLogger *DirLogger) Close() []error {
+	dirLogger.lock.Lock()
+	defer dirLogger.lock.Unlock()
 	errors := make([]error, 0)
 	for _, openFile := range dirLogger.OpenFiles {
 		err := openFile.Close()
@@ -123,12 +139,18 @@ func (dirLogger *DirLogger) Close() []error {
 	return errors
 }
 
+// Health reports the number of open files in the directory.
+func (dirLogger *DirLogger) Health() SinkHealth {
+	dirLogger.lock.Lock()
+	defer dirLogger.lock.Unlock()
+	return SinkHealth{OpenFiles: len(dirLogger.OpenFiles)}
+}
+
 // GetLoggerFactory opens a directory for writing logs by topic.
 func (dirLogger *DirLogger) GetLoggerFactory() LoggerFactory {
-	lock := &sync.Mutex{}
 	return func(topic string) Logger {
-		lock.Lock()
-		defer lock.Unlock()
+		dirLogger.lock.Lock()
+		defer dirLogger.lock.Unlock()
 		return dirLogger.Get(topic).Logger
 	}
 }
@@ -139,9 +161,9 @@ func NewDirLogger(dirContext FileWritingContext) (*DirLogger, error) {
 	if err != nil {
 		return nil, err
 	}
-	dirLogger := DirLogger{
+	dirLogger := &DirLogger{
 		DirContext: dirContext,
 		OpenFiles:  make([]FileWritingContext, 0),
 	}
-	return &dirLogger, nil
+	return dirLogger, nil
 }
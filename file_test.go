@@ -1,8 +1,11 @@
 package log4g
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -51,6 +54,111 @@ func TestInputStream(t *testing.T) {
 		_, err = is.NoPanic()
 		assert.NotNil(t, err)
 	})
+	t.Run("records", func(t *testing.T) {
+		rs, err := NewRecordInput(FileWritingContext{
+			CallDelimiter:    "\r\n",
+			ValuesDelimiters: " | ",
+			Path:             path,
+		})
+		assert.Nil(t, err)
+		logs, err := rs.ReadAll()
+		assert.Nil(t, err)
+		assert.Equal(t, InMemoryLogs{
+			{"hello", "world", "1"},
+			{"world", "hello", "2"},
+			{"hello", "world", "3"},
+		}, logs)
+	})
+}
+
+func TestRecordInput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "records")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	write := func(fwc FileWritingContext) FileWritingContext {
+		assert.Nil(t, fwc.Init())
+		fwc.Logger(ERROR, "stack trace:\nmain.go:12", F("n", 41))
+		fwc.Logger(INFO, "done")
+		assert.Nil(t, fwc.Close())
+		return fwc
+	}
+	read := func(fwc FileWritingContext) InMemoryLogs {
+		rs, err := NewRecordInput(fwc)
+		assert.Nil(t, err)
+		logs, err := rs.ReadAll()
+		assert.Nil(t, err)
+		return logs
+	}
+	t.Run("text", func(t *testing.T) {
+		fwc := write(FileWritingContext{
+			CallDelimiter:    "\n---\n",
+			ValuesDelimiters: " | ",
+			Path:             filepath.Join(dir, "text"),
+		})
+		assert.Equal(t, InMemoryLogs{
+			{ERROR, "stack trace:\nmain.go:12", "n=41"},
+			{INFO, "done"},
+		}, read(fwc))
+	})
+	t.Run("json", func(t *testing.T) {
+		fwc := write(FileWritingContext{
+			CallDelimiter: "\n",
+			Encoder:       JSONEncoder{},
+			Path:          filepath.Join(dir, "json"),
+		})
+		assert.Equal(t, InMemoryLogs{
+			{ERROR, "stack trace:\nmain.go:12", F("n", 41)},
+			{INFO, "done"},
+		}, read(fwc))
+	})
+	t.Run("logfmt", func(t *testing.T) {
+		fwc := write(FileWritingContext{
+			CallDelimiter: "\n",
+			Encoder:       LogfmtEncoder{},
+			Path:          filepath.Join(dir, "logfmt"),
+		})
+		// CallDelimiter defaults to "\n"
+		fwc.CallDelimiter = ""
+		assert.Equal(t, InMemoryLogs{
+			{ERROR, "stack trace:\nmain.go:12", F("n", "41")},
+			{INFO, "done"},
+		}, read(fwc))
+	})
+	t.Run("csv", func(t *testing.T) {
+		fwc := write(FileWritingContext{
+			CallDelimiter: "\n",
+			Encoder:       CSVEncoder{},
+			Path:          filepath.Join(dir, "csv"),
+		})
+		assert.Equal(t, InMemoryLogs{
+			{ERROR, "stack trace:\nmain.go:12", "n=41"},
+			{INFO, "done"},
+		}, read(fwc))
+		assert.Nil(t, ioutil.WriteFile(fwc.Path, []byte("[INFO] ,\"open\nvalue\n"), 0644))
+		rs, err := NewRecordInput(fwc)
+		assert.Nil(t, err)
+		_, err = rs.ReadAll()
+		assert.EqualError(t, err, `unterminated quoted CSV value in "[INFO] ,\"open\nvalue"`)
+	})
+	t.Run("errors", func(t *testing.T) {
+		_, err := NewRecordInput(FileWritingContext{Path: filepath.Join(dir, "missing")})
+		assert.NotNil(t, err)
+		_, err = NewRecordInput(FileWritingContext{Path: filepath.Join(dir, "text"), Encoder: plainEncoder{}})
+		assert.NotNil(t, err)
+		_, err = NewDelimitedInput(filepath.Join(dir, "text"), "")
+		assert.NotNil(t, err)
+		rs, err := NewRecordInput(FileWritingContext{Path: filepath.Join(dir, "text"), Encoder: JSONEncoder{}})
+		assert.Nil(t, err)
+		_, err = rs.ReadAll()
+		assert.EqualError(t, err, "entry 1: JSON entry isn't an object: \"[ERROR] | stack trace:\"")
+	})
+}
+
+// plainEncoder is an Encoder without a Decode method.
+type plainEncoder struct{}
+
+func (plainEncoder) Encode(buffer *bytes.Buffer, level string, values ...interface{}) {
+	buffer.WriteString(level)
 }
 
 func TestDirLogger(t *testing.T) {
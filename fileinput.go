@@ -2,7 +2,9 @@ package log4g
 
 import (
 	"bufio"
+	"fmt"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/potatomasterrace/catch"
@@ -54,3 +56,118 @@ func NewFileInput(path string) (InputStream, error) {
 		return nil
 	}, nil
 }
+
+// Record is a logged entry parsed back into its level and values.
+type Record struct {
+	Level  string
+	Values []interface{}
+}
+
+// RecordStream is a shortcut for reading records one by one, returns nil at the end.
+// It can panic (see NoPanic method)
+type RecordStream func() *Record
+
+// NoPanic intercept an eventual panic and returns it as an error.
+func (rs RecordStream) NoPanic() (*Record, error) {
+	var record *Record
+	err := catch.Error(func() {
+		record = rs()
+	})
+	return record, err
+}
+
+// ReadAll reads the remaining records, up to the first error.
+func (rs RecordStream) ReadAll() (InMemoryLogs, error) {
+	logs := make(InMemoryLogs, 0)
+	for {
+		record, err := rs.NoPanic()
+		if err != nil || record == nil {
+			return logs, err
+		}
+		logs = append(logs, prependValue(record.Level, record.Values))
+	}
+}
+
+// NewDelimitedInput creates a new InputStream of the entries separated by delimiter,
+// so entries spanning several lines are read whole.
+// It reads the file with a FileReadingContext, use one directly to follow the file.
+func NewDelimitedInput(path string, delimiter string) (InputStream, error) {
+	if delimiter == "" {
+		return nil, fmt.Errorf("empty delimiter for reading %s", path)
+	}
+	frc := &FileReadingContext{Path: path, Delimiter: delimiter}
+	err := frc.Init()
+	if err != nil {
+		return nil, err
+	}
+	return frc.InputStream, nil
+}
+
+// Records parses the entries of the stream with decoder, skipping empty entries.
+// It panics on entries the decoder can't parse.
+func (is InputStream) Records(decoder Decoder) RecordStream {
+	entryNumber := 0
+	return func() *Record {
+		for {
+			entry := is()
+			if entry == nil {
+				return nil
+			}
+			entryNumber++
+			if strings.TrimSpace(*entry) == "" {
+				continue
+			}
+			level, values, err := decoder.Decode(*entry)
+			if err != nil {
+				panic(fmt.Errorf("entry %d: %v", entryNumber, err))
+			}
+			return &Record{Level: level, Values: values}
+		}
+	}
+}
+
+// csvRecords joins the entries of the stream split inside a quoted CSV value,
+// which leaves its quotes unbalanced, with the delimiter they were split on.
+// It panics on a quoted value still open at the end of the stream.
+func csvRecords(is InputStream, delimiter string) InputStream {
+	return func() *string {
+		entry := is()
+		if entry == nil {
+			return nil
+		}
+		record := *entry
+		// quotes inside quoted values are doubled, so an odd count means an open value
+		for strings.Count(record, `"`)%2 == 1 {
+			next := is()
+			if next == nil {
+				panic(fmt.Errorf("unterminated quoted CSV value in %q", record))
+			}
+			record += delimiter + *next
+		}
+		return &record
+	}
+}
+
+// NewRecordInput creates a RecordStream of the file written with the settings of fwc:
+// its Path, CallDelimiter and Encoder, or FormatingFunc and ValuesDelimiters.
+// CallDelimiter defaults to "\n" if field empty.
+// Values written by a TextEncoder or CSVEncoder are read as strings,
+// CSV values holding the CallDelimiter are read whole.
+func NewRecordInput(fwc FileWritingContext) (RecordStream, error) {
+	decoder, ok := fwc.encoder().(Decoder)
+	if !ok {
+		return nil, fmt.Errorf("encoder %T of %s can't decode", fwc.encoder(), fwc.Path)
+	}
+	delimiter := fwc.CallDelimiter
+	if delimiter == "" {
+		delimiter = "\n"
+	}
+	is, err := NewDelimitedInput(fwc.Path, delimiter)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := decoder.(CSVEncoder); ok {
+		is = csvRecords(is, delimiter)
+	}
+	return is.Records(decoder), nil
+}
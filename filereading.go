@@ -0,0 +1,461 @@
+package log4g
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// maxEntrySize is the size above which an entry is considered malformed.
+const maxEntrySize = 16 * 1024 * 1024
+
+// fingerprintSize is the max number of bytes hashed to identify a file.
+const fingerprintSize = 1024
+
+// Checkpoint is a position in a file, identified by the hash of its first bytes
+// so a rotated or truncated file isn't resumed at the wrong place.
+type Checkpoint struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset"`
+	// Fingerprint is the hex encoded sha256 hash of the first FingerprintSize bytes of the file.
+	Fingerprint     string `json:"fingerprint"`
+	FingerprintSize int    `json:"fingerprint_size"`
+}
+
+// newCheckpoint returns the checkpoint at offset of the file starting with head.
+func newCheckpoint(path string, offset int64, head []byte) Checkpoint {
+	size := len(head)
+	if offset < int64(size) {
+		size = int(offset)
+	}
+	hash := sha256.Sum256(head[:size])
+	return Checkpoint{Path: path, Offset: offset, Fingerprint: hex.EncodeToString(hash[:]), FingerprintSize: size}
+}
+
+// Valid reports whether file is the file of the checkpoint and holds its offset.
+func (checkpoint Checkpoint) Valid(file *os.File) (bool, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	if checkpoint.Offset < 0 || info.Size() < checkpoint.Offset || checkpoint.FingerprintSize < 0 {
+		return false, nil
+	}
+	head := make([]byte, checkpoint.FingerprintSize)
+	_, err = file.ReadAt(head, 0)
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return newCheckpoint(checkpoint.Path, checkpoint.Offset, head) == checkpoint, nil
+}
+
+// LoadCheckpoint reads a checkpoint file, returns nil if it doesn't exist.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := &Checkpoint{}
+	err = json.Unmarshal(content, checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %v", path, err)
+	}
+	return checkpoint, nil
+}
+
+// Save writes the checkpoint to path atomically,
+// through a temporary file renamed over path.
+func (checkpoint Checkpoint) Save(path string) error {
+	content, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path+".tmp", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(content)
+	if err == nil {
+		err = file.Sync()
+	}
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// FileReadingContext stores the data for reading the entries of a file,
+// and following it as it grows like tail -F.
+type FileReadingContext struct {
+	InputStream
+	Path string
+	// Delimiter separates the entries.
+	// Defaults to "\n" if field empty.
+	Delimiter string
+	// Follow waits for new entries at the end of the file instead of ending the stream.
+	// The file is reopened when it is rotated or truncated.
+	Follow bool
+	// FromEnd starts reading at the end of the file, skipping its current entries.
+	FromEnd bool
+	// Offset is the byte offset to start reading at.
+	Offset int64
+	// Resume starts reading at the checkpoint if the file is still the one it identifies,
+	// at the start of the file otherwise. It replaces FromEnd and Offset.
+	// Defaults to the checkpoint saved at CheckpointPath if any.
+	Resume *Checkpoint
+	// CheckpointPath is the file the checkpoint of the entries processed is saved to.
+	// An entry is processed once the stream is called again, so the entries
+	// are read at least once across restarts.
+	CheckpointPath string
+	// CheckpointInterval is the min delay between two saves of the checkpoint,
+	// it is also saved at the end of the stream and on Close.
+	// Defaults to 1s if field empty.
+	CheckpointInterval time.Duration
+	// PollInterval is the delay between two checks for new entries when following.
+	// Defaults to 250ms if field empty.
+	PollInterval time.Duration
+	// Context ends the stream when done.
+	// Defaults to context.Background() if field empty.
+	Context context.Context
+	file    *os.File
+	info    os.FileInfo
+	// offset is the byte offset of the file read so far.
+	offset int64
+	// pending holds the bytes read but not returned yet.
+	pending []byte
+	chunk   []byte
+	// head holds the first bytes of the file, up to fingerprintSize.
+	head []byte
+	// returned is the offset after the last entry returned,
+	// processed the offset after the entries processed.
+	returned  int64
+	processed int64
+	saved     time.Time
+	// draining is set when the file was rotated, its rest is read before reopening.
+	draining bool
+	cancel   context.CancelFunc
+	lock     sync.Mutex
+}
+
+// Init opens the file and sets the InputStream.
+// The stream returns nil at the end of the file, or once the context is done when following.
+func (frc *FileReadingContext) Init() error {
+	if frc.Delimiter == "" {
+		frc.Delimiter = "\n"
+	}
+	if frc.PollInterval <= 0 {
+		frc.PollInterval = 250 * time.Millisecond
+	}
+	if frc.CheckpointInterval <= 0 {
+		frc.CheckpointInterval = time.Second
+	}
+	if frc.Context == nil {
+		frc.Context = context.Background()
+	}
+	file, err := os.Open(frc.Path)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	offset, err := frc.startOffset(file, info)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	head := make([]byte, fingerprintSize)
+	n, err := file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		file.Close()
+		return err
+	}
+	if int64(n) > offset {
+		n = int(offset)
+	}
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	ctx, cancel := context.WithCancel(frc.Context)
+	frc.lock.Lock()
+	frc.file, frc.info, frc.offset = file, info, offset
+	frc.pending, frc.chunk, frc.draining = nil, make([]byte, 32*1024), false
+	frc.head, frc.returned, frc.processed = head[:n], offset, offset
+	frc.cancel = cancel
+	frc.lock.Unlock()
+	frc.InputStream = func() *string {
+		return frc.next(ctx)
+	}
+	return nil
+}
+
+// startOffset returns the offset to start reading file at.
+func (frc *FileReadingContext) startOffset(file *os.File, info os.FileInfo) (int64, error) {
+	if frc.Resume == nil && frc.CheckpointPath != "" {
+		checkpoint, err := LoadCheckpoint(frc.CheckpointPath)
+		if err != nil {
+			return 0, err
+		}
+		frc.Resume = checkpoint
+	}
+	if frc.Resume != nil {
+		if frc.Resume.Path != frc.Path {
+			return 0, nil
+		}
+		valid, err := frc.Resume.Valid(file)
+		if err != nil || !valid {
+			return 0, err
+		}
+		return frc.Resume.Offset, nil
+	}
+	offset := frc.Offset
+	if frc.FromEnd {
+		offset = info.Size()
+	}
+	if offset > info.Size() {
+		return 0, fmt.Errorf("offset %d beyond the end of %s", offset, frc.Path)
+	}
+	return offset, nil
+}
+
+// next returns the next entry, waiting for it when following.
+// Calling it marks the last entry returned as processed.
+func (frc *FileReadingContext) next(ctx context.Context) *string {
+	err := frc.process(false)
+	if err != nil {
+		frc.closeFile()
+		panic(err)
+	}
+	for {
+		if ctx.Err() != nil {
+			frc.end()
+			return nil
+		}
+		entry, err := frc.read()
+		if err != nil {
+			frc.closeFile()
+			panic(err)
+		}
+		if entry != nil {
+			return entry
+		}
+		if !frc.Follow {
+			rest := frc.rest()
+			if rest == nil {
+				frc.end()
+			}
+			return rest
+		}
+		entry, waiting, err := frc.reopen()
+		if err != nil {
+			frc.closeFile()
+			panic(err)
+		}
+		if entry != nil {
+			return entry
+		}
+		if waiting {
+			select {
+			case <-ctx.Done():
+			case <-time.After(frc.PollInterval):
+			}
+		}
+	}
+}
+
+// read returns the next complete entry, or nil at the end of the file.
+func (frc *FileReadingContext) read() (*string, error) {
+	frc.lock.Lock()
+	defer frc.lock.Unlock()
+	delimiter := []byte(frc.Delimiter)
+	for frc.file != nil {
+		if i := bytes.Index(frc.pending, delimiter); i >= 0 {
+			entry := string(frc.pending[:i])
+			frc.pending = frc.pending[i+len(delimiter):]
+			frc.returned = frc.offset - int64(len(frc.pending))
+			return &entry, nil
+		}
+		if len(frc.pending) > maxEntrySize {
+			return nil, fmt.Errorf("entry of %s longer than %d bytes", frc.Path, maxEntrySize)
+		}
+		n, err := frc.file.Read(frc.chunk)
+		frc.pending = append(frc.pending, frc.chunk[:n]...)
+		if missing := fingerprintSize - len(frc.head); missing > 0 && frc.offset == int64(len(frc.head)) {
+			if missing > n {
+				missing = n
+			}
+			frc.head = append(frc.head, frc.chunk[:missing]...)
+		}
+		frc.offset += int64(n)
+		if err == io.EOF && n == 0 {
+			return nil, nil
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// rest returns the bytes left after the last delimiter as an entry, or nil if none.
+func (frc *FileReadingContext) rest() *string {
+	frc.lock.Lock()
+	defer frc.lock.Unlock()
+	if len(frc.pending) == 0 {
+		return nil
+	}
+	entry := string(frc.pending)
+	frc.pending = nil
+	frc.returned = frc.offset
+	return &entry
+}
+
+// process marks the entries returned as processed,
+// and saves the checkpoint if CheckpointInterval elapsed or force is set.
+func (frc *FileReadingContext) process(force bool) error {
+	frc.lock.Lock()
+	defer frc.lock.Unlock()
+	if frc.file == nil {
+		return nil
+	}
+	frc.processed = frc.returned
+	if frc.CheckpointPath == "" || !force && time.Since(frc.saved) < frc.CheckpointInterval {
+		return nil
+	}
+	frc.saved = time.Now()
+	return newCheckpoint(frc.Path, frc.processed, frc.head).Save(frc.CheckpointPath)
+}
+
+// end saves the checkpoint and closes the file at the end of the stream.
+func (frc *FileReadingContext) end() {
+	err := frc.process(true)
+	frc.closeFile()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Checkpoint returns the checkpoint after the last entry returned.
+func (frc *FileReadingContext) Checkpoint() Checkpoint {
+	frc.lock.Lock()
+	defer frc.lock.Unlock()
+	return newCheckpoint(frc.Path, frc.returned, frc.head)
+}
+
+// reopen checks whether the file was rotated or truncated at the end of the file.
+// A rotated file is read to its end before the new file is opened,
+// its unterminated last entry is then returned.
+// waiting is true when no new data is expected before the next poll.
+func (frc *FileReadingContext) reopen() (entry *string, waiting bool, err error) {
+	frc.lock.Lock()
+	defer frc.lock.Unlock()
+	if frc.file == nil {
+		return nil, true, nil
+	}
+	if frc.draining {
+		file, err := os.Open(frc.Path)
+		if os.IsNotExist(err) {
+			return nil, true, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, false, err
+		}
+		frc.file.Close()
+		if len(frc.pending) > 0 {
+			rest := string(frc.pending)
+			entry = &rest
+		}
+		frc.file, frc.info, frc.offset, frc.pending, frc.draining = file, info, 0, nil, false
+		frc.head, frc.returned, frc.processed = nil, 0, 0
+		return entry, false, nil
+	}
+	info, err := os.Stat(frc.Path)
+	if os.IsNotExist(err) {
+		// renamed, the new file isn't created yet
+		return nil, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if !os.SameFile(info, frc.info) {
+		frc.draining = true
+		return nil, false, nil
+	}
+	if info.Size() < frc.offset {
+		_, err = frc.file.Seek(0, io.SeekStart)
+		frc.offset, frc.pending = 0, nil
+		frc.head, frc.returned, frc.processed = nil, 0, 0
+		return nil, false, err
+	}
+	return nil, true, nil
+}
+
+// closeFile closes the file, if open.
+func (frc *FileReadingContext) closeFile() error {
+	frc.lock.Lock()
+	defer frc.lock.Unlock()
+	if frc.file == nil {
+		return nil
+	}
+	err := frc.file.Close()
+	frc.file = nil
+	return err
+}
+
+// Close ends the stream, saves the checkpoint of the entries processed
+// and closes the file, if open.
+// The last entry returned isn't processed yet.
+func (frc *FileReadingContext) Close() error {
+	if frc.cancel != nil {
+		frc.cancel()
+	}
+	frc.lock.Lock()
+	var err error
+	if frc.file != nil && frc.CheckpointPath != "" {
+		err = newCheckpoint(frc.Path, frc.processed, frc.head).Save(frc.CheckpointPath)
+	}
+	frc.lock.Unlock()
+	closeErr := frc.closeFile()
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Health reports whether the file is open.
+func (frc *FileReadingContext) Health() SinkHealth {
+	frc.lock.Lock()
+	defer frc.lock.Unlock()
+	if frc.file == nil {
+		return SinkHealth{}
+	}
+	return SinkHealth{OpenFiles: 1}
+}
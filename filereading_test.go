@@ -0,0 +1,210 @@
+package log4g
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// appendFile appends content to the file at path.
+func appendFile(t *testing.T, path string, content string) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	assert.Nil(t, err)
+	_, err = file.WriteString(content)
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+}
+
+// follow reads the stream in a goroutine.
+func follow(is InputStream) <-chan *string {
+	entries := make(chan *string)
+	go func() {
+		for {
+			entry := is()
+			entries <- entry
+			if entry == nil {
+				return
+			}
+		}
+	}()
+	return entries
+}
+
+// expect receives the next entry of a followed stream.
+func expect(t *testing.T, entries <-chan *string) string {
+	select {
+	case entry := <-entries:
+		if entry == nil {
+			t.Fatal("stream ended")
+		}
+		return *entry
+	case <-time.After(5 * time.Second):
+		t.Fatal("no entry received")
+	}
+	return ""
+}
+
+func TestFileReadingContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reading")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log")
+	appendFile(t, path, "hello\nmulti\r\nline\r\nend")
+	frc := FileReadingContext{Path: path, Delimiter: "\r\n"}
+	assert.Nil(t, frc.Init())
+	assert.Equal(t, 1, frc.Health().OpenFiles)
+	lines := make([]string, 0)
+	for line := frc.InputStream(); line != nil; line = frc.InputStream() {
+		lines = append(lines, *line)
+	}
+	assert.Equal(t, []string{"hello\nmulti", "line", "end"}, lines)
+	assert.Nil(t, frc.InputStream())
+	assert.Equal(t, SinkHealth{}, frc.Health())
+	assert.Nil(t, frc.Close())
+
+	frc = FileReadingContext{Path: path, Offset: 6}
+	assert.Nil(t, frc.Init())
+	assert.Equal(t, "multi\r", *frc.InputStream())
+	assert.Nil(t, frc.Close())
+	assert.Nil(t, frc.InputStream())
+
+	frc = FileReadingContext{Path: path, Offset: 100}
+	assert.NotNil(t, frc.Init())
+	frc = FileReadingContext{Path: filepath.Join(dir, "missing")}
+	assert.NotNil(t, frc.Init())
+}
+
+func TestFileReadingContextFollow(t *testing.T) {
+	dir, err := ioutil.TempDir("", "follow")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log")
+	appendFile(t, path, "old\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	frc := FileReadingContext{Path: path, Follow: true, FromEnd: true, PollInterval: 5 * time.Millisecond, Context: ctx}
+	assert.Nil(t, frc.Init())
+	defer frc.Close()
+	entries := follow(frc.InputStream)
+
+	appendFile(t, path, "first\nsec")
+	assert.Equal(t, "first", expect(t, entries))
+	appendFile(t, path, "ond\n")
+	assert.Equal(t, "second", expect(t, entries))
+
+	t.Run("rotation", func(t *testing.T) {
+		appendFile(t, path, "last of old")
+		assert.Nil(t, os.Rename(path, path+".1"))
+		time.Sleep(20 * time.Millisecond)
+		appendFile(t, path, "new\n")
+		assert.Equal(t, "last of old", expect(t, entries))
+		assert.Equal(t, "new", expect(t, entries))
+	})
+	t.Run("truncation", func(t *testing.T) {
+		assert.Nil(t, os.Truncate(path, 0))
+		time.Sleep(20 * time.Millisecond)
+		appendFile(t, path, "truncated\n")
+		assert.Equal(t, "truncated", expect(t, entries))
+	})
+	t.Run("cancel", func(t *testing.T) {
+		cancel()
+		select {
+		case entry := <-entries:
+			assert.Nil(t, entry)
+		case <-time.After(5 * time.Second):
+			t.Fatal("stream not ended")
+		}
+		assert.Equal(t, SinkHealth{}, frc.Health())
+	})
+}
+
+func TestCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log")
+	appendFile(t, path, "hello\nworld\n")
+	checkpoint := newCheckpoint(path, 6, []byte("hello\nworld\n"))
+	assert.Equal(t, 6, checkpoint.FingerprintSize)
+	file, err := os.Open(path)
+	assert.Nil(t, err)
+	defer file.Close()
+	valid, err := checkpoint.Valid(file)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+	checkpoint.Offset = 100
+	valid, err = checkpoint.Valid(file)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+	valid, err = newCheckpoint(path, 6, []byte("other\n")).Valid(file)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+
+	checkpointPath := filepath.Join(dir, "checkpoint")
+	loaded, err := LoadCheckpoint(checkpointPath)
+	assert.Nil(t, err)
+	assert.Nil(t, loaded)
+	assert.Nil(t, checkpoint.Save(checkpointPath))
+	loaded, err = LoadCheckpoint(checkpointPath)
+	assert.Nil(t, err)
+	assert.Equal(t, checkpoint, *loaded)
+	_, err = os.Stat(checkpointPath + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+	appendFile(t, checkpointPath, "garbage")
+	_, err = LoadCheckpoint(checkpointPath)
+	assert.NotNil(t, err)
+}
+
+func TestFileReadingContextCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resume")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log")
+	checkpointPath := filepath.Join(dir, "checkpoint")
+	appendFile(t, path, "first\nsecond\nthird\n")
+	open := func() *FileReadingContext {
+		frc := &FileReadingContext{Path: path, CheckpointPath: checkpointPath, CheckpointInterval: time.Nanosecond}
+		assert.Nil(t, frc.Init())
+		return frc
+	}
+
+	frc := open()
+	assert.Equal(t, "first", *frc.InputStream())
+	assert.Equal(t, "second", *frc.InputStream())
+	assert.Equal(t, int64(13), frc.Checkpoint().Offset)
+	// second isn't processed yet
+	assert.Nil(t, frc.Close())
+	saved, err := LoadCheckpoint(checkpointPath)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), saved.Offset)
+
+	frc = open()
+	assert.Equal(t, "second", *frc.InputStream())
+	assert.Equal(t, "third", *frc.InputStream())
+	assert.Nil(t, frc.InputStream())
+	assert.Nil(t, frc.Close())
+	saved, err = LoadCheckpoint(checkpointPath)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(19), saved.Offset)
+
+	appendFile(t, path, "fourth\n")
+	frc = open()
+	assert.Equal(t, "fourth", *frc.InputStream())
+	assert.Nil(t, frc.InputStream())
+
+	// the file was replaced, it is read from its start
+	assert.Nil(t, os.Remove(path))
+	appendFile(t, path, "rotated\n")
+	frc = open()
+	assert.Equal(t, "rotated", *frc.InputStream())
+	assert.Nil(t, frc.Close())
+
+	frc = &FileReadingContext{Path: path, Resume: &Checkpoint{Path: path, Offset: 100}}
+	assert.Nil(t, frc.Init())
+	assert.Equal(t, "rotated", *frc.InputStream())
+	assert.Nil(t, frc.Close())
+}
@@ -0,0 +1,142 @@
+package log4g
+
+import (
+	"bytes"
+	"runtime"
+	"sync"
+)
+
+// FlightRecorder configures the FlightRecorder combinator.
+type FlightRecorder struct {
+	// Size is the number of records kept by each buffer.
+	// Defaults to 100 if field empty.
+	Size int
+	// Threshold is the least severe level logged directly,
+	// the less severe records are buffered.
+	// Defaults to INFO if field empty.
+	Threshold string
+	// Trigger is the least severe level flushing the buffer before being logged.
+	// Defaults to ERROR if field empty.
+	Trigger string
+	// Key selects the buffer of a call, such as GoroutineKey or FieldKey(SpanIDKey).
+	// Defaults to a single buffer if field empty.
+	Key func(level string, values []interface{}) string
+	// MaxBuffers is the max number of buffers, the oldest buffer is discarded beyond.
+	// Defaults to 1024 if field empty.
+	MaxBuffers int
+}
+
+// withDefaults returns the recorder with its empty fields set to their defaults.
+func (recorder FlightRecorder) withDefaults() FlightRecorder {
+	if recorder.Size <= 0 {
+		recorder.Size = 100
+	}
+	if recorder.Threshold == "" {
+		recorder.Threshold = INFO
+	}
+	if recorder.Trigger == "" {
+		recorder.Trigger = ERROR
+	}
+	if recorder.Key == nil {
+		recorder.Key = func(string, []interface{}) string { return "" }
+	}
+	if recorder.MaxBuffers <= 0 {
+		recorder.MaxBuffers = 1024
+	}
+	return recorder
+}
+
+// GoroutineKey keeps a buffer per goroutine.
+func GoroutineKey(level string, values []interface{}) string {
+	var stack [64]byte
+	header := stack[:runtime.Stack(stack[:], false)]
+	// header starts with "goroutine 18 [running]:"
+	header = bytes.TrimPrefix(header, []byte("goroutine "))
+	if i := bytes.IndexByte(header, ' '); i >= 0 {
+		header = header[:i]
+	}
+	return string(header)
+}
+
+// FieldKey keeps a buffer per value of the field named key, such as a request id.
+// Calls without the field share a buffer.
+func FieldKey(key string) func(level string, values []interface{}) string {
+	return func(level string, values []interface{}) string {
+		for _, value := range values {
+			if field, ok := value.(Field); ok && field.Key == key {
+				buffer := getBuffer()
+				defer putBuffer(buffer)
+				field.writeText(buffer, "")
+				return buffer.String()
+			}
+		}
+		return ""
+	}
+}
+
+// recorderBuffer is the buffer of a key.
+type recorderBuffer struct {
+	logger Logger
+	ring   *RingBuffer
+}
+
+// FlightRecorder keeps the last records below the threshold in memory instead of logging them.
+// A record at or above the trigger level logs the buffered records of its key first,
+// so the context of an error is logged without paying for it otherwise.
+// Unknown levels are logged directly and never trigger.
+func (logger Logger) FlightRecorder(recorder FlightRecorder) Logger {
+	recorder = recorder.withDefaults()
+	lock := &sync.Mutex{}
+	buffers := make(map[string]recorderBuffer)
+	var keys []string
+	return func(level string, values ...interface{}) {
+		if Severity(level) < 0 {
+			logger(level, values...)
+			return
+		}
+		key := recorder.Key(level, values)
+		if !enabled(recorder.Threshold, level) {
+			lock.Lock()
+			buffer, ok := buffers[key]
+			if !ok {
+				if len(keys) >= recorder.MaxBuffers {
+					delete(buffers, keys[0])
+					keys = keys[1:]
+				}
+				buffer.logger, buffer.ring = NewRingBuffer(recorder.Size)
+				buffers[key] = buffer
+				keys = append(keys, key)
+			}
+			lock.Unlock()
+			buffer.logger(level, values...)
+			return
+		}
+		if enabled(recorder.Trigger, level) {
+			lock.Lock()
+			buffer, ok := buffers[key]
+			lock.Unlock()
+			if ok {
+				for _, record := range buffer.ring.Drain() {
+					logger(record[0].(string), record[1:]...)
+				}
+			}
+		}
+		logger(level, values...)
+	}
+}
+
+// FlightRecorder keeps the buffers of FlightRecorder per topic.
+func (lf LoggerFactory) FlightRecorder(recorder FlightRecorder) LoggerFactory {
+	lock := &sync.Mutex{}
+	loggers := make(map[string]Logger)
+	return func(topic string) Logger {
+		lock.Lock()
+		defer lock.Unlock()
+		logger, ok := loggers[topic]
+		if !ok {
+			logger = lf(topic).FlightRecorder(recorder)
+			loggers[topic] = logger
+		}
+		return logger
+	}
+}
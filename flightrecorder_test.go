@@ -0,0 +1,81 @@
+package log4g
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlightRecorder(t *testing.T) {
+	logger, logs := NewInMemoryLogger()
+	recorder := logger.FlightRecorder(FlightRecorder{Size: 2})
+	recorder(DEBUG, "dropped by the size")
+	recorder(TRACE, "step", 1)
+	recorder(INFO, "logged")
+	recorder(DEBUG, "step", 2)
+	assert.Equal(t, InMemoryLogs{{INFO, "logged"}}, *logs)
+	recorder(ERROR, "failed")
+	assert.Equal(t, InMemoryLogs{
+		{INFO, "logged"},
+		{TRACE, "step", 1},
+		{DEBUG, "step", 2},
+		{ERROR, "failed"},
+	}, *logs)
+	recorder(FATAL, "nothing buffered")
+	recorder("topic", "unknown level")
+	assert.Equal(t, InMemoryLogs{{FATAL, "nothing buffered"}, {"topic", "unknown level"}}, (*logs)[4:])
+}
+
+func TestFlightRecorderKeys(t *testing.T) {
+	logger, logs := NewInMemoryLogger()
+	recorder := logger.FlightRecorder(FlightRecorder{
+		Threshold:  WARN,
+		Trigger:    WARN,
+		Key:        FieldKey("request"),
+		MaxBuffers: 2,
+	})
+	recorder(INFO, "a1", String("request", "a"))
+	recorder(INFO, "b1", String("request", "b"))
+	recorder(INFO, "c1", String("request", "c"))
+	recorder(WARN, "a failed", String("request", "a"))
+	recorder(WARN, "b failed", String("request", "b"))
+	assert.Equal(t, InMemoryLogs{
+		{WARN, "a failed", String("request", "a")},
+		{INFO, "b1", String("request", "b")},
+		{WARN, "b failed", String("request", "b")},
+	}, *logs)
+}
+
+func TestFlightRecorderGoroutines(t *testing.T) {
+	logger, ring := NewRingBuffer(100)
+	recorder := logger.FlightRecorder(FlightRecorder{Key: GoroutineKey})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			recorder(DEBUG, i)
+			if i == 0 {
+				recorder(ERROR, i)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, InMemoryLogs{{DEBUG, 0}, {ERROR, 0}}, ring.Snapshot())
+	assert.NotEqual(t, "", GoroutineKey(INFO, nil))
+}
+
+func TestLoggerFactoryFlightRecorder(t *testing.T) {
+	loggers := make(map[string]*InMemoryLogs)
+	lf := LoggerFactory(func(topic string) Logger {
+		logger, logs := NewInMemoryLogger()
+		loggers[topic] = logs
+		return logger
+	}).FlightRecorder(FlightRecorder{})
+	lf("db")(DEBUG, "query")
+	lf("http")(DEBUG, "request")
+	lf("db")(ERROR, "deadlock")
+	assert.Equal(t, InMemoryLogs{{DEBUG, "query"}, {ERROR, "deadlock"}}, *loggers["db"])
+	assert.Equal(t, InMemoryLogs{}, *loggers["http"])
+}
@@ -0,0 +1,232 @@
+package log4g
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Type tags of the values encoded by WriteJSONLines.
+const (
+	nilTag      = "nil"
+	stringTag   = "string"
+	intTag      = "int"
+	int64Tag    = "int64"
+	float64Tag  = "float64"
+	boolTag     = "bool"
+	durationTag = "duration"
+	timeTag     = "time"
+	errorTag    = "error"
+	callerTag   = "caller"
+	fieldTag    = "field"
+	jsonTag     = "json"
+	textTag     = "text"
+)
+
+// taggedValue is a value encoded with its type.
+type taggedValue struct {
+	Type  string          `json:"t"`
+	Key   string          `json:"k,omitempty"`
+	Value json.RawMessage `json:"v,omitempty"`
+}
+
+// callerJSON is a Caller without its MarshalText method.
+type callerJSON Caller
+
+// taggedRecord is a record encoded by WriteJSONLines.
+type taggedRecord struct {
+	Level  string        `json:"level"`
+	Values []taggedValue `json:"values"`
+}
+
+// marshalJSON is json.Marshal without HTML escaping.
+func marshalJSON(value interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(value)
+	return bytes.TrimSuffix(buffer.Bytes(), []byte{'\n'}), err
+}
+
+// newTaggedValue encodes a value with its type.
+// Values of other types are encoded as JSON, or as text if JSON fails.
+func newTaggedValue(value interface{}) (taggedValue, error) {
+	var tag string
+	switch typed := value.(type) {
+	case nil:
+		return taggedValue{Type: nilTag}, nil
+	case string:
+		tag = stringTag
+	case int:
+		tag = intTag
+	case int64:
+		tag = int64Tag
+		value = strconv.FormatInt(typed, 10)
+	case float64:
+		tag = float64Tag
+		value = strconv.FormatFloat(typed, 'g', -1, 64)
+	case bool:
+		tag = boolTag
+	case time.Duration:
+		tag = durationTag
+		value = int64(typed)
+	case time.Time:
+		tag = timeTag
+		value = typed.Format(time.RFC3339Nano)
+	case Caller:
+		tag = callerTag
+		value = callerJSON(typed)
+	case Field:
+		nested, err := newTaggedValue(typed.Value())
+		if err != nil {
+			return taggedValue{}, err
+		}
+		encoded, err := marshalJSON(nested)
+		return taggedValue{Type: fieldTag, Key: typed.Key, Value: encoded}, err
+	case error:
+		tag = errorTag
+		value = typed.Error()
+	default:
+		encoded, err := marshalJSON(value)
+		if err == nil {
+			return taggedValue{Type: jsonTag, Value: encoded}, nil
+		}
+		tag = textTag
+		value = fmt.Sprint(value)
+	}
+	encoded, err := marshalJSON(value)
+	return taggedValue{Type: tag, Value: encoded}, err
+}
+
+// decode returns the value.
+// Values encoded as JSON are decoded as by encoding/json into an interface{}.
+func (tv taggedValue) decode() (interface{}, error) {
+	var err error
+	switch tv.Type {
+	case nilTag:
+		return nil, nil
+	case stringTag, textTag:
+		var s string
+		err = json.Unmarshal(tv.Value, &s)
+		return s, err
+	case intTag:
+		var i int
+		err = json.Unmarshal(tv.Value, &i)
+		return i, err
+	case int64Tag:
+		var s string
+		err = json.Unmarshal(tv.Value, &s)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.ParseInt(s, 10, 64)
+	case float64Tag:
+		var s string
+		err = json.Unmarshal(tv.Value, &s)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.ParseFloat(s, 64)
+	case boolTag:
+		var b bool
+		err = json.Unmarshal(tv.Value, &b)
+		return b, err
+	case durationTag:
+		var d int64
+		err = json.Unmarshal(tv.Value, &d)
+		return time.Duration(d), err
+	case timeTag:
+		var s string
+		err = json.Unmarshal(tv.Value, &s)
+		if err != nil {
+			return nil, err
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	case errorTag:
+		var s string
+		err = json.Unmarshal(tv.Value, &s)
+		return errors.New(s), err
+	case callerTag:
+		var caller callerJSON
+		err = json.Unmarshal(tv.Value, &caller)
+		return Caller(caller), err
+	case fieldTag:
+		var nested taggedValue
+		err = json.Unmarshal(tv.Value, &nested)
+		if err != nil {
+			return nil, err
+		}
+		value, err := nested.decode()
+		return F(tv.Key, value), err
+	case jsonTag:
+		var value interface{}
+		err = json.Unmarshal(tv.Value, &value)
+		return value, err
+	}
+	return nil, fmt.Errorf("unknown value type %q", tv.Type)
+}
+
+// WriteJSONLines writes the records as JSON Lines, one record per line,
+// each value tagged with its type so ReadJSONLines restores it.
+// Values of other types are encoded as JSON, or as text if JSON fails.
+func (logs InMemoryLogs) WriteJSONLines(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+	for _, logValues := range logs {
+		level, _ := logValues[0].(string)
+		record := taggedRecord{Level: level, Values: make([]taggedValue, len(logValues)-1)}
+		for i, value := range logValues[1:] {
+			tagged, err := newTaggedValue(value)
+			if err != nil {
+				return err
+			}
+			record.Values[i] = tagged
+		}
+		err := encoder.Encode(record)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadJSONLines reads records written by WriteJSONLines.
+// Errors are restored with errors.New and values encoded as JSON as by encoding/json.
+func ReadJSONLines(reader io.Reader) (InMemoryLogs, error) {
+	logs := make(InMemoryLogs, 0)
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record taggedRecord
+		err := json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			return logs, fmt.Errorf("line %d: %v", line, err)
+		}
+		logValues := make([]interface{}, len(record.Values)+1)
+		logValues[0] = record.Level
+		for i, tagged := range record.Values {
+			logValues[i+1], err = tagged.decode()
+			if err != nil {
+				return logs, fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+		logs = append(logs, logValues)
+	}
+	return logs, scanner.Err()
+}
+
+// Replay calls logger with each record.
+func (logs InMemoryLogs) Replay(logger Logger) {
+	for _, logValues := range logs {
+		level, _ := logValues[0].(string)
+		logger(level, logValues[1:]...)
+	}
+}
@@ -0,0 +1,53 @@
+package log4g
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// typedLogs returns records holding a value of each tagged type.
+func typedLogs() InMemoryLogs {
+	logger, logs := NewInMemoryLogger()
+	date := time.Date(2018, 11, 29, 0, 38, 11, 5, time.UTC)
+	logger(INFO, "is prime", 41, int64(math.MaxInt64), 0.5, true, nil, time.Second, date)
+	logger(ERROR, errors.New("failed"), Caller{Function: "log4g.fib", File: "log4g/fib.go", Line: 12})
+	logger(DEBUG, Int("n", 41), Int64("big", 42), String("s", "<b>"), Float64("inf", math.Inf(1)),
+		Bool("ok", false), Duration("elapsed", time.Millisecond), Time(TimeKey, date), Err(errors.New("e")))
+	logger("topic", []int{1, 41}, map[string]int{"n": 41}, complex(1, 2))
+	return *logs
+}
+
+func TestInMemoryLogsJSONLines(t *testing.T) {
+	logs := typedLogs()
+	var buffer bytes.Buffer
+	assert.Nil(t, logs.WriteJSONLines(&buffer))
+	golden, err := ioutil.ReadFile("testdata/inmemorylogs.jsonl")
+	assert.Nil(t, err)
+	assert.Equal(t, string(golden), buffer.String())
+
+	decoded, err := ReadJSONLines(&buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, logs[:3], decoded[:3])
+	assert.Equal(t, InMemoryLogs{{"topic", []interface{}{1.0, 41.0}, map[string]interface{}{"n": 41.0}, "(1+2i)"}}, decoded[3:])
+}
+
+func TestReadJSONLinesErrors(t *testing.T) {
+	_, err := ReadJSONLines(strings.NewReader("{\"level\":\"x\"}\n\nnot json\n"))
+	assert.EqualError(t, err, "line 3: invalid character 'o' in literal null (expecting 'u')")
+	_, err = ReadJSONLines(strings.NewReader(`{"level":"x","values":[{"t":"unknown"}]}`))
+	assert.EqualError(t, err, `line 1: unknown value type "unknown"`)
+}
+
+func TestInMemoryLogsReplay(t *testing.T) {
+	logger, logs := NewInMemoryLogger()
+	typedLogs().Replay(logger.Prepend("replayed"))
+	assert.Equal(t, 4, logs.Count())
+	assert.Equal(t, []interface{}{ERROR, "replayed", errors.New("failed"), Caller{Function: "log4g.fib", File: "log4g/fib.go", Line: 12}}, (*logs)[1])
+}
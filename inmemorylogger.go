@@ -3,6 +3,9 @@ package log4g
 import (
 	"bytes"
 	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
 )
 
 // InMemoryLogs is an abstraction for logs in memory
@@ -22,7 +25,110 @@ func (logs InMemoryLogs) StringArray(valueDelimiter string) []string {
 	return lines
 }
 
+// Encode transforms the logs to strings with the encoder.
+func (logs InMemoryLogs) Encode(encoder Encoder) []string {
+	lines := make([]string, len(logs))
+	for i, logValues := range logs {
+		level, _ := logValues[0].(string)
+		lines[i] = EncodeString(encoder, level, logValues[1:]...)
+	}
+	return lines
+}
+
+// filter returns the records for which keep returns true.
+func (logs InMemoryLogs) filter(keep func(level string, values []interface{}) bool) InMemoryLogs {
+	filtered := make(InMemoryLogs, 0)
+	for _, logValues := range logs {
+		level, _ := logValues[0].(string)
+		if keep(level, logValues[1:]) {
+			filtered = append(filtered, logValues)
+		}
+	}
+	return filtered
+}
+
+// Level returns the records at level.
+// level can be a level constant or its name in any case, see ParseLevel.
+func (logs InMemoryLogs) Level(level string) InMemoryLogs {
+	if parsed, err := ParseLevel(level); err == nil {
+		level = parsed
+	}
+	return logs.filter(func(recordLevel string, values []interface{}) bool {
+		return recordLevel == level
+	})
+}
+
+// Contains returns the records holding a value equal to value.
+func (logs InMemoryLogs) Contains(value interface{}) InMemoryLogs {
+	return logs.filter(func(level string, values []interface{}) bool {
+		for _, recordValue := range values {
+			if reflect.DeepEqual(recordValue, value) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// recordText formats the values of a record separated by spaces, fields as key=value.
+func recordText(values []interface{}) string {
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+	for i, value := range values {
+		if i > 0 {
+			buffer.WriteByte(' ')
+		}
+		writeValue(buffer, value, "")
+	}
+	return buffer.String()
+}
+
+// ContainsString returns the records whose text holds substr.
+// The text is the values separated by spaces, fields as key=value.
+func (logs InMemoryLogs) ContainsString(substr string) InMemoryLogs {
+	return logs.filter(func(level string, values []interface{}) bool {
+		return strings.Contains(recordText(values), substr)
+	})
+}
+
+// Match returns the records whose text matches pattern.
+// The text is the values separated by spaces, fields as key=value.
+func (logs InMemoryLogs) Match(pattern *regexp.Regexp) InMemoryLogs {
+	return logs.filter(func(level string, values []interface{}) bool {
+		return pattern.MatchString(recordText(values))
+	})
+}
+
+// Field returns the records holding a field named key equal to value,
+// of the same type: Int64 fields only match int64 values.
+func (logs InMemoryLogs) Field(key string, value interface{}) InMemoryLogs {
+	expected := F(key, value).Value()
+	return logs.filter(func(level string, values []interface{}) bool {
+		for _, recordValue := range values {
+			field, ok := recordValue.(Field)
+			if ok && field.Key == key && reflect.DeepEqual(field.Value(), expected) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Count returns the number of records.
+func (logs InMemoryLogs) Count() int {
+	return len(logs)
+}
+
+// Last returns the last record, its level followed by its values, or nil without records.
+func (logs InMemoryLogs) Last() []interface{} {
+	if len(logs) == 0 {
+		return nil
+	}
+	return logs[len(logs)-1]
+}
+
 // NewInMemoryLogger create a logger that outputs values to buffer.
+// It isn't safe for concurrent use, see NewRingBuffer.
 func NewInMemoryLogger() (Logger Logger, buffer *InMemoryLogs) {
 	var logBuffer InMemoryLogs = make([][]interface{}, 0)
 	return func(level string, values ...interface{}) {
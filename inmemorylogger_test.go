@@ -1,6 +1,7 @@
 package log4g
 
 import (
+	"regexp"
 	"testing"
 	"time"
 
@@ -18,3 +19,26 @@ func TestInMemoryLogger(t *testing.T) {
 	assert.Contains(t, loggedLines[0], "hello 1")
 	assert.Contains(t, loggedLines[1], "world 2")
 }
+
+func TestInMemoryLogsQueries(t *testing.T) {
+	logger, logs := NewInMemoryLogger()
+	logger(INFO, "41", "is prime", Int("n", 41))
+	logger(DEBUG, "42", "isn't prime", Int("n", 42))
+	logger(INFO, "43", "is prime", Int64("n", 43))
+	assert.Equal(t, 2, logs.Level(INFO).Count())
+	assert.Equal(t, 2, logs.Level("info").Count())
+	assert.Equal(t, InMemoryLogs{{DEBUG, "42", "isn't prime", Int("n", 42)}}, logs.Level("DEBUG"))
+	assert.Equal(t, 0, logs.Level(ERROR).Count())
+	assert.Equal(t, 1, logs.Contains("43").Count())
+	assert.Equal(t, 1, logs.Contains(Int("n", 42)).Count())
+	assert.Equal(t, 2, logs.ContainsString("is prime").Count())
+	assert.Equal(t, 1, logs.ContainsString("n=42").Count())
+	assert.Equal(t, 3, logs.Match(regexp.MustCompile(`^4\d `)).Count())
+	assert.Equal(t, 1, logs.Field("n", int64(43)).Count())
+	assert.Equal(t, 0, logs.Field("n", 43).Count())
+	assert.Equal(t, 1, logs.Field("n", 41).Count())
+	assert.Equal(t, 0, logs.Field("n", int64(41)).Count())
+	assert.Equal(t, 0, logs.Field("m", 41).Count())
+	assert.Equal(t, []interface{}{INFO, "43", "is prime", Int64("n", 43)}, logs.Level(INFO).ContainsString("43").Last())
+	assert.Nil(t, logs.Level(FATAL).Last())
+}
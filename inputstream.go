@@ -0,0 +1,262 @@
+package log4g
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/potatomasterrace/catch"
+)
+
+// Filter keeps the lines for which keep returns true.
+func (is InputStream) Filter(keep func(line string) bool) InputStream {
+	return func() *string {
+		for line := is(); line != nil; line = is() {
+			if keep(*line) {
+				return line
+			}
+		}
+		return nil
+	}
+}
+
+// Map replaces the lines with their result by fn.
+func (is InputStream) Map(fn func(line string) string) InputStream {
+	return func() *string {
+		line := is()
+		if line == nil {
+			return nil
+		}
+		mapped := fn(*line)
+		return &mapped
+	}
+}
+
+// Take ends the stream after n lines.
+// The rest of the stream isn't read, so a file isn't closed by reaching its end.
+func (is InputStream) Take(n int) InputStream {
+	taken := 0
+	return func() *string {
+		if taken >= n {
+			return nil
+		}
+		taken++
+		return is()
+	}
+}
+
+// Skip drops the first n lines.
+func (is InputStream) Skip(n int) InputStream {
+	skipped := 0
+	return func() *string {
+		for ; skipped < n; skipped++ {
+			if is() == nil {
+				return nil
+			}
+		}
+		return is()
+	}
+}
+
+// BatchStream is a shortcut for reading lines by batches, returns nil at the end.
+// It can panic (see NoPanic method)
+type BatchStream func() []string
+
+// NoPanic intercept an eventual panic and returns it as an error.
+func (bs BatchStream) NoPanic() ([]string, error) {
+	var batch []string
+	err := catch.Error(func() {
+		batch = bs()
+	})
+	return batch, err
+}
+
+// streamedLine is a line read by Batch in the background.
+type streamedLine struct {
+	line *string
+	err  error
+}
+
+// Batch groups the lines by batches of n lines, or of the lines read within maxDelay
+// of the first line of the batch, whichever comes first.
+// n <= 0 doesn't limit the size of the batches, maxDelay <= 0 their delay.
+// With a maxDelay the stream is read in a goroutine until its end or until ctx is done,
+// so a followed file can be batched without waiting for n lines.
+// The stream ends once ctx is done, the goroutine stops after its pending read:
+// give a followed FileReadingContext the same Context to end that read too.
+func (is InputStream) Batch(ctx context.Context, n int, maxDelay time.Duration) BatchStream {
+	if maxDelay <= 0 {
+		return func() []string {
+			var batch []string
+			for (n <= 0 || len(batch) < n) && ctx.Err() == nil {
+				line := is()
+				if line == nil {
+					break
+				}
+				batch = append(batch, *line)
+			}
+			return batch
+		}
+	}
+	var lines chan streamedLine
+	var ended bool
+	var err error
+	return func() []string {
+		if err != nil {
+			panic(err)
+		}
+		if ended || ctx.Err() != nil {
+			return nil
+		}
+		if lines == nil {
+			lines = make(chan streamedLine)
+			go func() {
+				for {
+					line, readErr := is.NoPanic()
+					select {
+					case lines <- streamedLine{line: line, err: readErr}:
+					case <-ctx.Done():
+						return
+					}
+					if line == nil || readErr != nil {
+						return
+					}
+				}
+			}()
+		}
+		var batch []string
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+		for n <= 0 || len(batch) < n {
+			select {
+			case next := <-lines:
+				if next.err != nil || next.line == nil {
+					ended, err = true, next.err
+					if err != nil && len(batch) == 0 {
+						panic(err)
+					}
+					return batch
+				}
+				batch = append(batch, *next.line)
+				if timer == nil {
+					timer = time.NewTimer(maxDelay)
+					timeout = timer.C
+				}
+			case <-timeout:
+				return batch
+			case <-ctx.Done():
+				ended = true
+				return batch
+			}
+		}
+		return batch
+	}
+}
+
+// lineTimeLayouts are the time layouts found by LineTime, with their patterns.
+var lineTimeLayouts = []struct {
+	pattern *regexp.Regexp
+	layout  string
+}{
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`), time.RFC3339Nano},
+	{regexp.MustCompile(`[A-Z][a-z]{2}, \d{2} [A-Z][a-z]{2} \d{4} \d{2}:\d{2}:\d{2} [+-]\d{4}`), time.RFC1123Z},
+	// zones without abbreviation, such as Asia/Dubai, are formatted as +04 by time.RFC1123
+	{regexp.MustCompile(`[A-Z][a-z]{2}, \d{2} [A-Z][a-z]{2} \d{4} \d{2}:\d{2}:\d{2} [+-]\d{2}\b`), "Mon, 02 Jan 2006 15:04:05 -07"},
+	{regexp.MustCompile(`[A-Z][a-z]{2}, \d{2} [A-Z][a-z]{2} \d{4} \d{2}:\d{2}:\d{2} [A-Z]{3,5}`), time.RFC1123},
+}
+
+// knownZone tells if the zone abbreviation of a time parsed with time.RFC1123 is UTC, GMT
+// or the local zone: time.Parse gives a zero offset to the other abbreviations.
+func knownZone(t time.Time) bool {
+	name, _ := t.Zone()
+	return t.Location() == time.UTC || t.Location() == time.Local || name == "GMT"
+}
+
+// LineTime returns the first RFC3339, RFC1123Z or RFC1123 time of a line,
+// such as the times written by the JSON and logfmt encoders or by PrependTime.
+// The zone of RFC1123 times must be UTC, GMT, the local zone or numeric such as +04:
+// times with other abbreviations, whose offset is unknown, are skipped.
+func LineTime(line string) (time.Time, bool) {
+	for _, layout := range lineTimeLayouts {
+		match := layout.pattern.FindString(line)
+		if match == "" {
+			continue
+		}
+		parsed, err := time.Parse(layout.layout, match)
+		if err == nil && (layout.layout != time.RFC1123 || knownZone(parsed)) {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Merge reads the streams in the order of the times of their lines, as returned by lineTime.
+// A line without time keeps the time of the previous line of its stream.
+// Lines of equal times are read in the order of the streams.
+// LineTime is used if lineTime is nil.
+func Merge(lineTime func(line string) (time.Time, bool), streams ...InputStream) InputStream {
+	if lineTime == nil {
+		lineTime = LineTime
+	}
+	heads := make([]*string, len(streams))
+	times := make([]time.Time, len(streams))
+	read := func(i int) {
+		heads[i] = streams[i]()
+		if heads[i] == nil {
+			return
+		}
+		if parsed, ok := lineTime(*heads[i]); ok {
+			times[i] = parsed
+		}
+	}
+	started := false
+	return func() *string {
+		if !started {
+			started = true
+			for i := range streams {
+				read(i)
+			}
+		}
+		next := -1
+		for i, head := range heads {
+			if head != nil && (next < 0 || times[i].Before(times[next])) {
+				next = i
+			}
+		}
+		if next < 0 {
+			return nil
+		}
+		line := heads[next]
+		read(next)
+		return line
+	}
+}
+
+// Pipe logs the lines of the stream, parsed with decoder as by InputStream.Records,
+// to logger until the end of the stream, at their decoded level or INFO if empty.
+// With a nil decoder the raw lines are logged at INFO.
+// A panic of the stream, the decoder or the logger is returned as an error.
+func Pipe(is InputStream, decoder Decoder, logger Logger) error {
+	if decoder == nil {
+		return catch.Error(func() {
+			for line := is(); line != nil; line = is() {
+				logger(INFO, *line)
+			}
+		})
+	}
+	records := is.Records(decoder)
+	return catch.Error(func() {
+		for record := records(); record != nil; record = records() {
+			level := record.Level
+			if level == "" {
+				level = INFO
+			}
+			logger(level, record.Values...)
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package log4g
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sliceInput returns an InputStream of lines.
+func sliceInput(lines ...string) InputStream {
+	return func() *string {
+		if len(lines) == 0 {
+			return nil
+		}
+		line := lines[0]
+		lines = lines[1:]
+		return &line
+	}
+}
+
+// readLines reads the stream to its end.
+func readLines(is InputStream) []string {
+	lines := make([]string, 0)
+	for line := is(); line != nil; line = is() {
+		lines = append(lines, *line)
+	}
+	return lines
+}
+
+func TestInputStreamCombinators(t *testing.T) {
+	numbers := func() InputStream {
+		return sliceInput("1", "2", "3", "4", "5")
+	}
+	odd := func(line string) bool { return strings.ContainsAny(line, "135") }
+	assert.Equal(t, []string{"1", "3", "5"}, readLines(numbers().Filter(odd)))
+	assert.Equal(t, []string{"n=1", "n=2"}, readLines(numbers().Take(2).Map(func(line string) string { return "n=" + line })))
+	assert.Equal(t, []string{"4", "5"}, readLines(numbers().Skip(3)))
+	assert.Equal(t, []string{}, readLines(numbers().Skip(10)))
+	assert.Equal(t, []string{"2", "3"}, readLines(numbers().Skip(1).Take(2)))
+}
+
+func TestInputStreamBatch(t *testing.T) {
+	batches := sliceInput("1", "2", "3", "4", "5").Batch(context.Background(), 2, 0)
+	assert.Equal(t, []string{"1", "2"}, batches())
+	assert.Equal(t, []string{"3", "4"}, batches())
+	assert.Equal(t, []string{"5"}, batches())
+	assert.Nil(t, batches())
+	assert.Equal(t, []string{"1", "2", "3"}, sliceInput("1", "2", "3").Batch(context.Background(), 0, 0)())
+
+	t.Run("delay", func(t *testing.T) {
+		lines := make(chan string)
+		is := func() *string {
+			line, ok := <-lines
+			if !ok {
+				return nil
+			}
+			return &line
+		}
+		batches := InputStream(is).Batch(context.Background(), 10, 200*time.Millisecond)
+		go func() {
+			lines <- "1"
+			lines <- "2"
+		}()
+		assert.Equal(t, []string{"1", "2"}, batches())
+		go func() {
+			lines <- "3"
+			close(lines)
+		}()
+		assert.Equal(t, []string{"3"}, batches())
+		assert.Nil(t, batches())
+	})
+	t.Run("panic", func(t *testing.T) {
+		calls := 0
+		is := func() *string {
+			calls++
+			if calls > 1 {
+				panic(errors.New("read failed"))
+			}
+			line := "1"
+			return &line
+		}
+		batches := InputStream(is).Batch(context.Background(), 10, time.Second)
+		batch, err := batches.NoPanic()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"1"}, batch)
+		_, err = batches.NoPanic()
+		assert.EqualError(t, err, "read failed")
+	})
+	t.Run("cancel", func(t *testing.T) {
+		is := func() *string {
+			line := "1"
+			return &line
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		batches := InputStream(is).Batch(ctx, 2, time.Second)
+		assert.Equal(t, []string{"1", "1"}, batches())
+		cancel()
+		assert.Nil(t, batches())
+		// the goroutine stops instead of waiting to send its next line
+		assert.Eventually(t, func() bool {
+			stacks := make([]byte, 1<<20)
+			stacks = stacks[:runtime.Stack(stacks, true)]
+			return !strings.Contains(string(stacks), "log4g.InputStream.Batch.")
+		}, 5*time.Second, time.Millisecond)
+		assert.Nil(t, InputStream(is).Batch(ctx, 2, 0)())
+	})
+}
+
+func TestLineTime(t *testing.T) {
+	expected := time.Date(2018, 11, 29, 0, 38, 11, 0, time.UTC)
+	for _, line := range []string{
+		`{"level":"INFO","time":"2018-11-29T00:38:11Z","msg":"is prime"}`,
+		`level=INFO time=2018-11-29T01:38:11+01:00 msg="is prime"`,
+		`[INFO]  Thu, 29 Nov 2018 01:38:11 +0100  is prime`,
+		`[INFO]  Thu, 29 Nov 2018 00:38:11 UTC  is prime`,
+		`[INFO]  Thu, 29 Nov 2018 00:38:11 GMT  is prime`,
+		`[INFO]  Thu, 29 Nov 2018 04:38:11 +04  is prime`,
+		`[INFO]  ` + expected.Local().Format(time.RFC1123) + `  is prime`,
+	} {
+		parsed, ok := LineTime(line)
+		assert.True(t, ok, line)
+		assert.True(t, expected.Equal(parsed), line)
+	}
+	_, ok := LineTime("is prime")
+	assert.False(t, ok)
+	// abbreviations other than the local zone have an unknown offset
+	for _, zone := range []string{"CET", "JST"} {
+		if zone != expected.Local().Format("MST") {
+			_, ok = LineTime(`[INFO]  Thu, 29 Nov 2018 00:38:11 ` + zone + `  is prime`)
+			assert.False(t, ok, zone)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	first := sliceInput(
+		"time=2018-11-29T00:00:01Z a1",
+		"a1 continued",
+		"time=2018-11-29T00:00:04Z a4",
+	)
+	second := sliceInput(
+		"time=2018-11-29T00:00:01Z b1",
+		"time=2018-11-29T00:00:02Z b2",
+		"time=2018-11-29T00:00:05Z b5",
+	)
+	assert.Equal(t, []string{
+		"time=2018-11-29T00:00:01Z a1",
+		"a1 continued",
+		"time=2018-11-29T00:00:01Z b1",
+		"time=2018-11-29T00:00:02Z b2",
+		"time=2018-11-29T00:00:04Z a4",
+		"time=2018-11-29T00:00:05Z b5",
+	}, readLines(Merge(nil, first, second, sliceInput())))
+	assert.Equal(t, []string{}, readLines(Merge(nil)))
+}
+
+func TestPipe(t *testing.T) {
+	logger, logs := NewInMemoryLogger()
+	err := Pipe(sliceInput(
+		`{"level":"DEBUG","msg":"square root 6","n":41}`,
+		`{"msg":"no level"}`,
+	), JSONEncoder{}, logger)
+	assert.Nil(t, err)
+	err = Pipe(sliceInput("[ERROR] | disk full", "[WARN]  | is | prime"), TextEncoder{ValuesDelimiters: " | "}, logger)
+	assert.Nil(t, err)
+	assert.Equal(t, InMemoryLogs{
+		{DEBUG, "square root 6", F("n", 41)},
+		{INFO, "no level"},
+		{ERROR, "disk full"},
+		{WARN, "is", "prime"},
+	}, *logs)
+	err = Pipe(sliceInput("raw line", ""), nil, logger)
+	assert.Nil(t, err)
+	assert.Equal(t, InMemoryLogs{{INFO, "raw line"}, {INFO, ""}}, (*logs)[4:])
+	err = Pipe(sliceInput("not json"), JSONEncoder{}, logger)
+	assert.EqualError(t, err, "entry 1: invalid character 'o' in literal null (expecting 'u')")
+	err = Pipe(sliceInput("plain"), nil, func(level string, values ...interface{}) {
+		panic(errors.New("write failed"))
+	})
+	assert.EqualError(t, err, "write failed")
+}
@@ -0,0 +1,217 @@
+package log4g
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// journalSocket is the path of the journald native protocol socket.
+const journalSocket = "/run/systemd/journal/socket"
+
+// JournalContext stores the data for sending logged values to the systemd journal
+// with the native protocol.
+// Fields are sent as uppercase journal fields, FunCall headers and Caller fields
+// as CODE_FUNC, CODE_FILE and CODE_LINE.
+// Calls are written as text to Fallback when the socket is missing,
+// and entries too large for a datagram are written to Fallback too.
+type JournalContext struct {
+	Logger
+	// Address is the path of the journal socket.
+	// Defaults to "/run/systemd/journal/socket" if field empty.
+	Address string
+	// Identifier is the SYSLOG_IDENTIFIER of the entries.
+	// Defaults to the program name if field empty.
+	Identifier string
+	// Fallback receives the calls when the journal is unreachable
+	// and the entries over the datagram size limit.
+	// Defaults to os.Stderr if field empty.
+	Fallback io.Writer
+	fallback Logger
+	conn     net.Conn
+	lock     sync.Mutex
+}
+
+// Init connects to the journal, or falls back to writing text.
+func (jc *JournalContext) Init() error {
+	if jc.Address == "" {
+		jc.Address = journalSocket
+	}
+	if jc.Identifier == "" {
+		jc.Identifier = filepath.Base(os.Args[0])
+	}
+	if jc.Fallback == nil {
+		jc.Fallback = os.Stderr
+	}
+	jc.fallback = NewWriterLogger(jc.Fallback, map[string]io.Writer{})
+	conn, err := net.Dial("unixgram", jc.Address)
+	if err != nil {
+		jc.Logger = jc.fallback
+		return nil
+	}
+	jc.conn = conn
+	jc.Logger = func(level string, values ...interface{}) {
+		buffer := getBuffer()
+		defer putBuffer(buffer)
+		jc.encode(buffer, level, values)
+		jc.lock.Lock()
+		defer jc.lock.Unlock()
+		if jc.conn == nil {
+			panic("writing to closed journal " + jc.Address)
+		}
+		_, err := jc.conn.Write(buffer.Bytes())
+		if errors.Is(err, syscall.EMSGSIZE) {
+			jc.fallback(level, values...)
+			return
+		}
+		if err != nil {
+			panic(err)
+		}
+	}
+	return nil
+}
+
+// encode writes a journal entry in the native protocol.
+func (jc *JournalContext) encode(buffer *bytes.Buffer, level string, values []interface{}) {
+	scratch := getBuffer()
+	defer putBuffer(scratch)
+	writeMessage(scratch, values, "")
+	writeJournalField(buffer, "MESSAGE", scratch.Bytes())
+	writeJournalField(buffer, "PRIORITY", []byte(strconv.Itoa(syslogSeverity(level))))
+	writeJournalField(buffer, "SYSLOG_IDENTIFIER", []byte(jc.Identifier))
+	hasCaller := false
+	eachField(values, func(field Field) {
+		if caller, ok := field.Value().(Caller); ok && field.Key == CallerKey {
+			hasCaller = true
+			writeJournalField(buffer, "CODE_FUNC", []byte(caller.Function))
+			writeJournalField(buffer, "CODE_FILE", []byte(caller.File))
+			writeJournalField(buffer, "CODE_LINE", []byte(strconv.Itoa(caller.Line)))
+			return
+		}
+		scratch.Reset()
+		field.writeText(scratch, "")
+		writeJournalField(buffer, journalFieldName(field.Key), scratch.Bytes())
+	})
+	if hasCaller {
+		return
+	}
+	for i := len(values) - 1; i >= 0; i-- {
+		header, ok := values[i].(string)
+		if !ok {
+			continue
+		}
+		function, file, line, ok := parseFunCallHeader(header)
+		if !ok {
+			continue
+		}
+		writeJournalField(buffer, "CODE_FUNC", []byte(function))
+		if file != "" {
+			writeJournalField(buffer, "CODE_FILE", []byte(file))
+			writeJournalField(buffer, "CODE_LINE", []byte(strconv.Itoa(line)))
+		}
+		return
+	}
+}
+
+// parseFunCallHeader parses the header prepended by FunCall or DetailedFunCall.
+// file and line are only set by DetailedFunCall.
+func parseFunCallHeader(header string) (function string, file string, line int, ok bool) {
+	if !strings.HasPrefix(header, " -> ") || !strings.HasSuffix(header, " : ") {
+		return "", "", 0, false
+	}
+	header = strings.TrimSuffix(header[len(" -> "):], " : ")
+	i := strings.IndexByte(header, ' ')
+	if i <= 0 {
+		return "", "", 0, false
+	}
+	function = header[:i]
+	// DetailedFunCall ends with "file:line", FunCall with the "]" of the arguments
+	fields := strings.Fields(header[i:])
+	if len(fields) >= 2 {
+		location := fields[len(fields)-1]
+		j := strings.LastIndexByte(location, ':')
+		if !strings.HasSuffix(location, "]") && j > 0 {
+			line, err := strconv.Atoi(location[j+1:])
+			if err == nil {
+				return function, location[:j], line, true
+			}
+		}
+	}
+	return function, "", 0, true
+}
+
+// journalFieldName converts a key to a journal field name:
+// uppercase letters, digits and underscores, starting with a letter.
+func journalFieldName(key string) string {
+	name := make([]byte, 0, len(key))
+	for i := 0; i < len(key) && len(name) < 64; i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		case c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' || c == '_':
+			if len(name) == 0 {
+				continue
+			}
+		default:
+			if len(name) == 0 {
+				continue
+			}
+			c = '_'
+		}
+		name = append(name, c)
+	}
+	if len(name) == 0 {
+		return "FIELD"
+	}
+	return string(name)
+}
+
+// writeJournalField writes a field of the native protocol,
+// with the binary length encoding if the value holds a newline.
+func writeJournalField(buffer *bytes.Buffer, name string, value []byte) {
+	buffer.WriteString(name)
+	if bytes.IndexByte(value, '\n') < 0 {
+		buffer.WriteByte('=')
+		buffer.Write(value)
+		buffer.WriteByte('\n')
+		return
+	}
+	var length [8]byte
+	binary.LittleEndian.PutUint64(length[:], uint64(len(value)))
+	buffer.WriteByte('\n')
+	buffer.Write(length[:])
+	buffer.Write(value)
+	buffer.WriteByte('\n')
+}
+
+// Close the connection to the journal, if any.
+func (jc *JournalContext) Close() error {
+	jc.lock.Lock()
+	defer jc.lock.Unlock()
+	if jc.conn == nil {
+		return nil
+	}
+	err := jc.conn.Close()
+	jc.conn = nil
+	return err
+}
+
+// Health reports whether the journal socket is open.
+func (jc *JournalContext) Health() SinkHealth {
+	jc.lock.Lock()
+	defer jc.lock.Unlock()
+	if jc.conn == nil {
+		return SinkHealth{}
+	}
+	return SinkHealth{OpenFiles: 1}
+}
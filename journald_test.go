@@ -0,0 +1,136 @@
+package log4g
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// parseJournalEntry parses a native protocol datagram.
+func parseJournalEntry(t *testing.T, entry []byte) map[string]string {
+	fields := make(map[string]string)
+	for len(entry) > 0 {
+		i := bytes.IndexAny(entry, "=\n")
+		if i < 0 {
+			t.Fatalf("malformed entry %q", entry)
+		}
+		name := string(entry[:i])
+		if entry[i] == '=' {
+			j := bytes.IndexByte(entry, '\n')
+			fields[name] = string(entry[i+1 : j])
+			entry = entry[j+1:]
+			continue
+		}
+		length := binary.LittleEndian.Uint64(entry[i+1 : i+9])
+		fields[name] = string(entry[i+9 : i+9+int(length)])
+		entry = entry[i+10+int(length):]
+	}
+	return fields
+}
+
+func TestJournalContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	address := filepath.Join(dir, "socket")
+	server, err := net.ListenPacket("unixgram", address)
+	assert.Nil(t, err)
+	defer server.Close()
+	jc := JournalContext{Address: address, Identifier: "prime"}
+	assert.Nil(t, jc.Init())
+	assert.Equal(t, 1, jc.Health().OpenFiles)
+	read := func() map[string]string {
+		server.SetReadDeadline(time.Now().Add(5 * time.Second))
+		entry := make([]byte, 4096)
+		n, _, err := server.ReadFrom(entry)
+		assert.Nil(t, err)
+		return parseJournalEntry(t, entry[:n])
+	}
+
+	jc.Logger.FunCall(41).DetailedFunCall()(ERROR, "line 1\nline 2", Int("n", 41), String("http.status", "ok"), String("_trusted", "no"))
+	entry := read()
+	assert.Equal(t, "3", entry["PRIORITY"])
+	assert.Equal(t, "prime", entry["SYSLOG_IDENTIFIER"])
+	assert.True(t, strings.HasSuffix(entry["MESSAGE"], "line 1\nline 2"))
+	assert.Equal(t, "41", entry["N"])
+	assert.Equal(t, "ok", entry["HTTP_STATUS"])
+	assert.Equal(t, "no", entry["TRUSTED"])
+	assert.Equal(t, "TestJournalContext", entry["CODE_FUNC"])
+	assert.Equal(t, "journald_test.go", entry["CODE_FILE"])
+	assert.NotEmpty(t, entry["CODE_LINE"])
+
+	jc.Logger.FunCall(41)(INFO, "plain")
+	entry = read()
+	assert.Equal(t, "6", entry["PRIORITY"])
+	assert.Equal(t, "TestJournalContext", entry["CODE_FUNC"])
+	assert.Equal(t, "", entry["CODE_FILE"])
+
+	jc.Logger.AddCaller(CallerFormat{})(WARN, "caller")
+	entry = read()
+	assert.Equal(t, "4", entry["PRIORITY"])
+	assert.Equal(t, "log4g.TestJournalContext", entry["CODE_FUNC"])
+	assert.True(t, strings.HasSuffix(entry["CODE_FILE"], "/journald_test.go"))
+
+	assert.Nil(t, jc.Close())
+	assert.Equal(t, 0, jc.Health().OpenFiles)
+}
+
+func TestJournalContextOversized(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	address := filepath.Join(dir, "socket")
+	server, err := net.ListenPacket("unixgram", address)
+	assert.Nil(t, err)
+	defer server.Close()
+	var fallback bytes.Buffer
+	jc := JournalContext{Address: address, Fallback: &fallback}
+	assert.Nil(t, jc.Init())
+	defer jc.Close()
+	oversized := strings.Repeat("x", 16<<20)
+	assert.Nil(t, jc.Logger.NoPanic(ERROR, "big", oversized))
+	assert.Contains(t, fallback.String(), "big "+oversized)
+}
+
+func TestJournalContextFallback(t *testing.T) {
+	var fallback bytes.Buffer
+	jc := JournalContext{Address: filepath.Join(os.TempDir(), "missing-journal"), Fallback: &fallback}
+	assert.Nil(t, jc.Init())
+	jc.Logger(ERROR, "no journal")
+	assert.Contains(t, fallback.String(), "no journal")
+	assert.Equal(t, SinkHealth{}, jc.Health())
+	assert.Nil(t, jc.Close())
+}
+
+func TestJournalFieldName(t *testing.T) {
+	assert.Equal(t, "REQUEST_ID", journalFieldName("request-id"))
+	assert.Equal(t, "A1", journalFieldName("_1a1"))
+	assert.Equal(t, "FIELD", journalFieldName("_"))
+}
+
+func TestParseFunCallHeader(t *testing.T) {
+	function, file, line, ok := parseFunCallHeader(" -> fib [1 2:3] : ")
+	assert.True(t, ok)
+	assert.Equal(t, "fib", function)
+	assert.Equal(t, "", file)
+	assert.Equal(t, 0, line)
+	function, file, line, ok = parseFunCallHeader(" -> (*T).fib [] fib.go:12 : ")
+	assert.True(t, ok)
+	assert.Equal(t, "(*T).fib", function)
+	assert.Equal(t, "fib.go", file)
+	assert.Equal(t, 12, line)
+	function, file, _, ok = parseFunCallHeader(" -> fib [a:1] : ")
+	assert.True(t, ok)
+	assert.Equal(t, "fib", function)
+	assert.Equal(t, "", file)
+	_, _, _, ok = parseFunCallHeader("message")
+	assert.False(t, ok)
+}
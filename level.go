@@ -0,0 +1,55 @@
+package log4g
+
+import (
+	"fmt"
+	"strings"
+)
+
+// levels lists the logging levels from the most to the least severe.
+var levels = []string{FATAL, ERROR, WARN, INFO, DEBUG, TRACE, ALL}
+
+// Severity returns the rank of a logging level, 0 being FATAL.
+// Returns -1 on unknown levels.
+func Severity(level string) int {
+	for i, knownLevel := range levels {
+		if knownLevel == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// LevelName returns the level without its brackets and padding.
+// "[WARN] " becomes "WARN", unknown levels are returned trimmed.
+func LevelName(level string) string {
+	return strings.TrimSpace(strings.Trim(strings.TrimSpace(level), "[]"))
+}
+
+// ParseLevel returns the logging level matching name.
+// name can be a level constant or its name in any case ("warn", "WARN", "[WARN] ").
+func ParseLevel(name string) (string, error) {
+	name = strings.ToUpper(LevelName(name))
+	for _, level := range levels {
+		if LevelName(level) == name {
+			return level, nil
+		}
+	}
+	return "", fmt.Errorf("unknown logging level %q", name)
+}
+
+// enabled tells if a call at level passes the threshold.
+// Unknown levels and thresholds always pass.
+func enabled(threshold string, level string) bool {
+	severity, maxSeverity := Severity(level), Severity(threshold)
+	return severity < 0 || maxSeverity < 0 || severity <= maxSeverity
+}
+
+// Threshold filters the levels less severe than threshold.
+// Unknown levels are not filtered.
+func (logger Logger) Threshold(threshold string) Logger {
+	return func(level string, values ...interface{}) {
+		if enabled(threshold, level) {
+			logger(level, values...)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package log4g
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevels(t *testing.T) {
+	assert.Equal(t, 0, Severity(FATAL))
+	assert.Equal(t, -1, Severity("file1"))
+	assert.Equal(t, "WARN", LevelName(WARN))
+	level, err := ParseLevel("warn")
+	assert.Nil(t, err)
+	assert.Equal(t, WARN, level)
+	level, err = ParseLevel(DEBUG)
+	assert.Nil(t, err)
+	assert.Equal(t, DEBUG, level)
+	_, err = ParseLevel("verbose")
+	assert.NotNil(t, err)
+	t.Run("threshold", func(t *testing.T) {
+		logger, buffer := NewInMemoryLogger()
+		logger = logger.Threshold(INFO)
+		logger(ERROR, "error")
+		logger(DEBUG, "debug")
+		logger(INFO, "info")
+		logger("topic", "unknown")
+		assert.Equal(t, []string{"[ERROR] error ", "[INFO]  info ", "topic unknown "}, buffer.StringArray(" "))
+	})
+}
@@ -0,0 +1,224 @@
+// Package log4gtest provides helpers for testing code logging with log4g.
+package log4gtest
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/potatomasterrace/log4g"
+	"github.com/stretchr/testify/assert"
+)
+
+// tHelper is implemented by *testing.T and *testing.B.
+type tHelper interface {
+	Helper()
+}
+
+// formatLogs lists the records, one per line, for failure messages.
+func formatLogs(logs log4g.InMemoryLogs) string {
+	if len(logs) == 0 {
+		return "  (no records)"
+	}
+	lines := logs.Encode(log4g.TextEncoder{ValuesDelimiters: " "})
+	for i, line := range lines {
+		lines[i] = "  " + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatValue formats a value for failure messages, quoting strings.
+func formatValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(value)
+}
+
+// recordDiff is the difference between a record and the expected one.
+type recordDiff struct {
+	// matched counts the expected words or values found in the record.
+	matched int
+	// level is the level of the record if it isn't the expected one.
+	level   string
+	missing []string
+	extra   []string
+}
+
+// mismatches counts the differences.
+func (diff recordDiff) mismatches() int {
+	count := len(diff.missing) + len(diff.extra)
+	if diff.level != "" {
+		count++
+	}
+	return count
+}
+
+// compareLevel keeps the level of the record if it isn't the expected one.
+func (diff *recordDiff) compareLevel(expected string, level string) {
+	if level != expected {
+		diff.level = level
+	}
+}
+
+// failureMessage shows the expected record next to the closest one, then every record.
+// The closest record holds the most expected words or values, then has the fewest differences.
+func failureMessage(summary string, expected string, logs log4g.InMemoryLogs, compare func(level string, values []interface{}) recordDiff) string {
+	var closest log4g.InMemoryLogs
+	var closestDiff recordDiff
+	for _, record := range logs {
+		level, _ := record[0].(string)
+		diff := compare(level, record[1:])
+		if closest == nil || diff.matched > closestDiff.matched ||
+			diff.matched == closestDiff.matched && diff.mismatches() < closestDiff.mismatches() {
+			closest, closestDiff = log4g.InMemoryLogs{record}, diff
+		}
+	}
+	lines := []string{summary, "  expected: " + expected}
+	if closest == nil {
+		return strings.Join(append(lines, "  (no records)"), "\n")
+	}
+	lines = append(lines, "  closest:  "+closest.Encode(log4g.TextEncoder{ValuesDelimiters: " "})[0])
+	if closestDiff.level != "" {
+		lines = append(lines, "  level:    "+log4g.LevelName(closestDiff.level))
+	}
+	if len(closestDiff.missing) > 0 {
+		lines = append(lines, "  missing:  "+strings.Join(closestDiff.missing, " "))
+	}
+	if len(closestDiff.extra) > 0 {
+		lines = append(lines, "  extra:    "+strings.Join(closestDiff.extra, " "))
+	}
+	return strings.Join(append(lines, "Records:", formatLogs(logs)), "\n")
+}
+
+// parseLevel returns the level constant of level, or level if unknown.
+func parseLevel(level string) string {
+	if parsed, err := log4g.ParseLevel(level); err == nil {
+		return parsed
+	}
+	return level
+}
+
+// AssertLogged asserts that logs hold a record at level whose text contains substr.
+// The failure message shows the closest record, its level if it differs
+// and the words of substr missing from its text, then lists the records.
+func AssertLogged(t assert.TestingT, logs log4g.InMemoryLogs, level string, substr string, msgAndArgs ...interface{}) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	if logs.Level(level).ContainsString(substr).Count() > 0 {
+		return true
+	}
+	level = parseLevel(level)
+	summary := fmt.Sprintf("No %s record containing %q.", log4g.LevelName(level), substr)
+	expected := log4g.LevelName(level) + " containing " + formatValue(substr)
+	return assert.Fail(t, failureMessage(summary, expected, logs, func(recordLevel string, values []interface{}) recordDiff {
+		var diff recordDiff
+		diff.compareLevel(level, recordLevel)
+		record := log4g.InMemoryLogs{append([]interface{}{recordLevel}, values...)}
+		for _, word := range strings.Fields(substr) {
+			if record.ContainsString(word).Count() > 0 {
+				diff.matched++
+			} else {
+				diff.missing = append(diff.missing, formatValue(word))
+			}
+		}
+		if len(diff.missing) == 0 && record.ContainsString(substr).Count() == 0 {
+			// the words are there, but not together
+			diff.missing = append(diff.missing, formatValue(substr))
+		}
+		return diff
+	}), msgAndArgs...)
+}
+
+// AssertNotLogged asserts that logs hold no record at level whose text contains substr.
+// The failure message lists the matching records.
+func AssertNotLogged(t assert.TestingT, logs log4g.InMemoryLogs, level string, substr string, msgAndArgs ...interface{}) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	matching := logs.Level(level).ContainsString(substr)
+	if matching.Count() == 0 {
+		return true
+	}
+	return assert.Fail(t, fmt.Sprintf("Unexpected %s records containing %q:\n%s", log4g.LevelName(level), substr, formatLogs(matching)), msgAndArgs...)
+}
+
+// AssertField asserts that logs hold a record with a field named key equal to value.
+// The failure message shows the closest record, a record holding a field named key if any,
+// with the field missing and the fields named key it holds instead, then lists the records.
+func AssertField(t assert.TestingT, logs log4g.InMemoryLogs, key string, value interface{}, msgAndArgs ...interface{}) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	if logs.Field(key, value).Count() > 0 {
+		return true
+	}
+	expected := log4g.F(key, value)
+	summary := fmt.Sprintf("No record with field %s=%v.", key, value)
+	return assert.Fail(t, failureMessage(summary, expected.String(), logs, func(level string, values []interface{}) recordDiff {
+		diff := recordDiff{missing: []string{expected.String()}}
+		for _, recordValue := range values {
+			if field, ok := recordValue.(log4g.Field); ok && field.Key == key {
+				diff.matched = 1
+				diff.extra = append(diff.extra, field.String())
+			}
+		}
+		return diff
+	}), msgAndArgs...)
+}
+
+// AssertRecord asserts that logs hold a record at level made of values.
+// The failure message shows the closest record, its level if it differs,
+// the values it misses and its extra values, then lists the records.
+func AssertRecord(t assert.TestingT, logs log4g.InMemoryLogs, level string, values ...interface{}) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	level = parseLevel(level)
+	for _, record := range logs {
+		if record[0] == level && reflect.DeepEqual(record[1:], values) {
+			return true
+		}
+	}
+	expected := log4g.InMemoryLogs{append([]interface{}{level}, values...)}
+	summary := fmt.Sprintf("No %s record made of the values.", log4g.LevelName(level))
+	return assert.Fail(t, failureMessage(summary, expected.Encode(log4g.TextEncoder{ValuesDelimiters: " "})[0], logs,
+		func(recordLevel string, recordValues []interface{}) recordDiff {
+			var diff recordDiff
+			diff.compareLevel(level, recordLevel)
+			unmatched := append([]interface{}{}, recordValues...)
+			for _, value := range values {
+				found := false
+				for i, recordValue := range unmatched {
+					if reflect.DeepEqual(recordValue, value) {
+						unmatched = append(unmatched[:i], unmatched[i+1:]...)
+						found = true
+						break
+					}
+				}
+				if found {
+					diff.matched++
+				} else {
+					diff.missing = append(diff.missing, formatValue(value))
+				}
+			}
+			for _, value := range unmatched {
+				diff.extra = append(diff.extra, formatValue(value))
+			}
+			return diff
+		}))
+}
+
+// AssertCount asserts that logs hold count records.
+// The failure message lists the records.
+func AssertCount(t assert.TestingT, logs log4g.InMemoryLogs, count int, msgAndArgs ...interface{}) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	if logs.Count() == count {
+		return true
+	}
+	return assert.Fail(t, fmt.Sprintf("Expected %d records, got %d:\n%s", count, logs.Count(), formatLogs(logs)), msgAndArgs...)
+}
@@ -0,0 +1,83 @@
+package log4gtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/potatomasterrace/log4g"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockT records the failures of the assertions, without the indentation of testify.
+type mockT struct {
+	errors []string
+}
+
+func (m *mockT) Errorf(format string, args ...interface{}) {
+	m.errors = append(m.errors, strings.Replace(fmt.Sprintf(format, args...), "\n\t            \t", "\n", -1))
+}
+
+func TestAssertLogged(t *testing.T) {
+	logger, logs := log4g.NewInMemoryLogger()
+	logger(log4g.INFO, "41", "is prime", log4g.Int("n", 41))
+	logger(log4g.DEBUG, "42", "isn't prime")
+	assert.True(t, AssertLogged(t, *logs, log4g.INFO, "is prime"))
+	assert.True(t, AssertNotLogged(t, *logs, log4g.ERROR, "prime"))
+	assert.True(t, AssertField(t, *logs, "n", 41))
+	assert.True(t, AssertCount(t, logs.Level("debug"), 1))
+
+	mock := &mockT{}
+	assert.False(t, AssertLogged(mock, *logs, log4g.INFO, "42"))
+	assert.Contains(t, mock.errors[0], "No INFO record containing \"42\".\n"+
+		"  expected: INFO containing \"42\"\n"+
+		"  closest:  [DEBUG] 42 isn't prime\n"+
+		"  level:    DEBUG\n"+
+		"Records:\n"+
+		"  [INFO]  41 is prime n=41\n"+
+		"  [DEBUG] 42 isn't prime")
+	assert.False(t, AssertNotLogged(mock, *logs, log4g.DEBUG, "42"))
+	assert.Contains(t, mock.errors[1], "Unexpected DEBUG records containing \"42\":\n")
+	assert.NotContains(t, mock.errors[1], "[INFO]")
+	assert.False(t, AssertField(mock, *logs, "n", 42, "checking %d", 42))
+	assert.Contains(t, mock.errors[2], "No record with field n=42.\n"+
+		"  expected: n=42\n"+
+		"  closest:  [INFO]  41 is prime n=41\n"+
+		"  missing:  n=42\n"+
+		"  extra:    n=41\n")
+	assert.Contains(t, mock.errors[2], "checking 42")
+	assert.False(t, AssertCount(mock, logs.Level(log4g.FATAL), 1))
+	assert.Contains(t, mock.errors[3], "Expected 1 records, got 0:\n")
+	assert.Contains(t, mock.errors[3], "(no records)")
+	assert.False(t, AssertLogged(mock, *logs, log4g.INFO, "is not prime"))
+	assert.Contains(t, mock.errors[4], "  closest:  [INFO]  41 is prime n=41\n"+
+		"  missing:  \"not\"\n")
+	assert.False(t, AssertLogged(mock, *logs, log4g.INFO, "prime is"))
+	assert.Contains(t, mock.errors[5], "  closest:  [INFO]  41 is prime n=41\n"+
+		"  missing:  \"prime is\"\n")
+	assert.False(t, AssertLogged(mock, nil, log4g.INFO, "prime"))
+	assert.Contains(t, mock.errors[6], "  expected: INFO containing \"prime\"\n  (no records)")
+}
+
+func TestAssertRecord(t *testing.T) {
+	logger, logs := log4g.NewInMemoryLogger()
+	logger(log4g.INFO, "41", "is prime", log4g.Int("n", 41))
+	logger(log4g.DEBUG, "42", "isn't prime")
+	assert.True(t, AssertRecord(t, *logs, log4g.INFO, "41", "is prime", log4g.Int("n", 41)))
+	assert.True(t, AssertRecord(t, *logs, "debug", "42", "isn't prime"))
+
+	mock := &mockT{}
+	assert.False(t, AssertRecord(mock, *logs, log4g.INFO, "41", "is prime"))
+	assert.Contains(t, mock.errors[0], "No INFO record made of the values.\n"+
+		"  expected: [INFO]  41 is prime\n"+
+		"  closest:  [INFO]  41 is prime n=41\n"+
+		"  extra:    n=41\n"+
+		"Records:\n")
+	assert.False(t, AssertRecord(mock, *logs, log4g.WARN, "42", "isn't prime", 1))
+	assert.Contains(t, mock.errors[1], "  closest:  [DEBUG] 42 isn't prime\n"+
+		"  level:    DEBUG\n"+
+		"  missing:  1\n")
+	assert.False(t, AssertRecord(mock, *logs, log4g.INFO, 41, "is prime", log4g.Int("n", 41)))
+	assert.Contains(t, mock.errors[2], "  missing:  41\n"+
+		"  extra:    \"41\"\n")
+}
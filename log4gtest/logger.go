@@ -0,0 +1,65 @@
+package log4gtest
+
+import (
+	"sync"
+
+	"github.com/potatomasterrace/log4g"
+)
+
+// TB is the part of testing.TB used by NewLogger.
+type TB interface {
+	Helper()
+	Log(args ...interface{})
+	Errorf(format string, args ...interface{})
+	Cleanup(f func())
+}
+
+// Options configures NewLogger.
+type Options struct {
+	// FailOn fails the test on records at least as severe, such as log4g.ERROR or "error".
+	// The test never fails if field empty.
+	FailOn string
+	// Encoder formats the records written to the test log.
+	// Defaults to a log4g.TextEncoder if field empty.
+	Encoder log4g.Encoder
+}
+
+// NewLogger creates a logger writing to the log of the test, shown for failing tests
+// and verbose runs under the right subtest.
+// The records are collected in the returned InMemoryLogs.
+// Once the test has ended, the records are only collected.
+// Direct calls are attributed to their caller, calls through combinators to log4g.
+func NewLogger(t TB, options Options) (log4g.Logger, *log4g.InMemoryLogs) {
+	t.Helper()
+	if options.Encoder == nil {
+		options.Encoder = log4g.TextEncoder{ValuesDelimiters: " "}
+	}
+	if failOn, err := log4g.ParseLevel(options.FailOn); err == nil {
+		options.FailOn = failOn
+	}
+	lock := &sync.Mutex{}
+	logs := make(log4g.InMemoryLogs, 0)
+	ended := false
+	t.Cleanup(func() {
+		lock.Lock()
+		defer lock.Unlock()
+		ended = true
+	})
+	return func(level string, values ...interface{}) {
+		t.Helper()
+		record := append([]interface{}{level}, values...)
+		line := log4g.EncodeString(options.Encoder, level, values...)
+		lock.Lock()
+		defer lock.Unlock()
+		logs = append(logs, record)
+		if ended {
+			return
+		}
+		if options.FailOn != "" && log4g.Severity(level) >= 0 &&
+			log4g.Severity(level) <= log4g.Severity(options.FailOn) {
+			t.Errorf("%s", line)
+			return
+		}
+		t.Log(line)
+	}, &logs
+}
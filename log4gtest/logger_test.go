@@ -0,0 +1,63 @@
+package log4gtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/potatomasterrace/log4g"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTB records the calls of NewLogger.
+type fakeTB struct {
+	logs     []string
+	errors   []string
+	helpers  int
+	cleanups []func()
+}
+
+func (f *fakeTB) Helper() {
+	f.helpers++
+}
+
+func (f *fakeTB) Log(args ...interface{}) {
+	f.logs = append(f.logs, fmt.Sprint(args...))
+}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Cleanup(cleanup func()) {
+	f.cleanups = append(f.cleanups, cleanup)
+}
+
+func TestNewLogger(t *testing.T) {
+	tb := &fakeTB{}
+	logger, logs := NewLogger(tb, Options{FailOn: "error"})
+	logger(log4g.INFO, "is prime", 41)
+	logger(log4g.ERROR, "failed")
+	logger("topic", "unknown level")
+	assert.Equal(t, []string{"[INFO]  is prime 41", "topic unknown level"}, tb.logs)
+	assert.Equal(t, []string{"[ERROR] failed"}, tb.errors)
+	assert.True(t, tb.helpers >= 4)
+	for _, cleanup := range tb.cleanups {
+		cleanup()
+	}
+	logger(log4g.FATAL, "after the test")
+	assert.Equal(t, 2, len(tb.logs))
+	assert.Equal(t, 1, len(tb.errors))
+	assert.Equal(t, 4, logs.Count())
+	AssertLogged(t, *logs, log4g.FATAL, "after the test")
+}
+
+func TestNewLoggerWithTesting(t *testing.T) {
+	logger, logs := NewLogger(t, Options{Encoder: log4g.LogfmtEncoder{}})
+	t.Run("subtest", func(t *testing.T) {
+		sublogger, sublogs := NewLogger(t, Options{FailOn: log4g.FATAL})
+		sublogger.Error("shown under the subtest when failing")
+		AssertCount(t, *sublogs, 1)
+	})
+	logger.Info("is prime", log4g.Int("n", 41))
+	AssertField(t, *logs, "n", 41)
+}
@@ -0,0 +1,91 @@
+package log4gtest
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/potatomasterrace/log4g"
+	"github.com/stretchr/testify/assert"
+)
+
+// update is the -log4gtest.update flag of the tests importing log4gtest,
+// namespaced so it doesn't clash with an -update flag of the tests.
+var update = flag.Bool("log4gtest.update", false, "rewrite the log4gtest golden files")
+
+// volatile are the patterns replaced by Normalize, in order.
+var volatile = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	// RFC1123 times of PrependTime, zones without abbreviation are numeric such as +04
+	{regexp.MustCompile(`[A-Z][a-z]{2}, \d{2} [A-Z][a-z]{2} \d{4} \d{2}:\d{2}:\d{2} ([A-Z]{3,5}|[+-]\d{2,4})`), "<time>"},
+	// RFC3339 times
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`), "<time>"},
+	// goroutines count of PrependGoRoutines
+	{regexp.MustCompile(`\[ Go routines : \d+ \]`), "[ Go routines : <n> ]"},
+	// pointers
+	{regexp.MustCompile(`0x[0-9a-f]+`), "<ptr>"},
+	// durations
+	{regexp.MustCompile(`\b(\d+(\.\d+)?(h|m|s|ms|µs|us|ns))+\b`), "<duration>"},
+}
+
+// Normalize replaces the volatile parts of log output: times, goroutine counts,
+// pointers and durations.
+func Normalize(text string) string {
+	for _, v := range volatile {
+		text = v.pattern.ReplaceAllString(text, v.replacement)
+	}
+	return text
+}
+
+// goldenPath returns the path of a golden file.
+func goldenPath(name string) string {
+	return filepath.Join("testdata", name+".golden")
+}
+
+// AssertGolden asserts that text equals testdata/name.golden.
+// Running the tests with -log4gtest.update rewrites the golden file instead.
+func AssertGolden(t assert.TestingT, name string, text string, msgAndArgs ...interface{}) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	path := goldenPath(name)
+	if *update {
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			err = ioutil.WriteFile(path, []byte(text), 0644)
+		}
+		return assert.NoError(t, err, msgAndArgs...)
+	}
+	golden, err := ioutil.ReadFile(path)
+	if err != nil {
+		return assert.Fail(t, "Missing golden file "+path+", run the tests with -log4gtest.update to create it", msgAndArgs...)
+	}
+	return assert.Equal(t, string(golden), text, msgAndArgs...)
+}
+
+// Snapshot returns a logger whose output, normalised by Normalize, is compared
+// with testdata/name.golden when the test ends, see AssertGolden.
+func Snapshot(t TB, name string) log4g.Logger {
+	t.Helper()
+	lock := &sync.Mutex{}
+	var output strings.Builder
+	t.Cleanup(func() {
+		lock.Lock()
+		defer lock.Unlock()
+		AssertGolden(t, name, Normalize(output.String()))
+	})
+	encoder := log4g.TextEncoder{ValuesDelimiters: " "}
+	return func(level string, values ...interface{}) {
+		line := log4g.EncodeString(encoder, level, values...)
+		lock.Lock()
+		defer lock.Unlock()
+		output.WriteString(line)
+		output.WriteByte('\n')
+	}
+}
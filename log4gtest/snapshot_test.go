@@ -0,0 +1,66 @@
+package log4gtest
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/potatomasterrace/log4g"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "<time> [ Go routines : <n> ] -> fib [41] fib.go:12 : at <time> took <duration> <ptr>",
+		Normalize(time.Now().Format(time.RFC1123)+" [ Go routines : 12 ] -> fib [41] fib.go:12 : "+
+			"at 2018-11-29T00:38:11.5+01:00 took 1m2.5s 0xc000012345"))
+	assert.Equal(t, "41 is prime", Normalize("41 is prime"))
+}
+
+// primes logs the primes up to n.
+func primes(logger log4g.Logger, n int) {
+	logger = logger.PrependTime().PrependGoRoutines().DetailedFunCall(n)
+	start := time.Now()
+	for i := 2; i <= n; i++ {
+		prime := true
+		for j := 2; j*j <= i; j++ {
+			if i%j == 0 {
+				prime = false
+			}
+		}
+		if prime {
+			logger(log4g.INFO, i, "is prime")
+		}
+	}
+	logger(log4g.DEBUG, "took", time.Since(start), log4g.Time(log4g.TimeKey, time.Now()))
+}
+
+func TestSnapshot(t *testing.T) {
+	primes(Snapshot(t, "primes"), 7)
+}
+
+func TestSnapshotMismatch(t *testing.T) {
+	if *update {
+		t.Skip("would rewrite the golden files")
+	}
+	tb := &fakeTB{}
+	logger := Snapshot(tb, "primes")
+	primes(logger, 5)
+	missing := Snapshot(tb, "missing")
+	missing(log4g.INFO, "no golden file")
+	for _, cleanup := range tb.cleanups {
+		cleanup()
+	}
+	assert.Equal(t, 2, len(tb.errors))
+	assert.Contains(t, tb.errors[0], "-[INFO]  <time> [ Go routines : <n> ]  -> primes [7] snapshot_test.go:21 :  7 is prime")
+	assert.Contains(t, tb.errors[1], "Missing golden file testdata/missing.golden")
+}
+
+func TestNormalizeZones(t *testing.T) {
+	date := time.Date(2018, 11, 29, 0, 38, 11, 0, time.UTC)
+	for _, zone := range []*time.Location{time.UTC, time.FixedZone("+04", 4*60*60), time.FixedZone("-0330", -210*60), time.FixedZone("AEDT", 11*60*60)} {
+		assert.Equal(t, "<time> up", Normalize(date.In(zone).Format(time.RFC1123)+" up"), zone.String())
+	}
+}
+
+// testUpdate is an -update flag of the tests, as in the packages importing log4gtest.
+var testUpdate = flag.Bool("update", false, "unused, shouldn't clash with -log4gtest.update")
@@ -0,0 +1,145 @@
+package log4g
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// LogfmtEncoder encodes logger calls as logfmt key=value pairs.
+//
+//	level=INFO time=2018-11-29T00:38:11Z msg="square root 6" n=41
+//
+// The values that aren't fields are joined in the msg key.
+type LogfmtEncoder struct {
+	// Layout of the time values.
+	// Defaults to time.RFC3339Nano if field empty.
+	TimeLayout string
+}
+
+// Encode implements Encoder.
+func (enc LogfmtEncoder) Encode(buffer *bytes.Buffer, level string, values ...interface{}) {
+	scratch := getBuffer()
+	defer putBuffer(scratch)
+	writeLogfmtKey(buffer, LevelKey)
+	scratch.WriteString(LevelName(level))
+	writeLogfmtValue(buffer, scratch.Bytes())
+	writeField := func(field Field) {
+		buffer.WriteByte(' ')
+		writeLogfmtKey(buffer, field.Key)
+		scratch.Reset()
+		field.writeText(scratch, enc.TimeLayout)
+		writeLogfmtValue(buffer, scratch.Bytes())
+	}
+	eachField(values, func(field Field) {
+		if isHeader(field) {
+			writeField(field)
+		}
+	})
+	if hasMessage(values) {
+		buffer.WriteByte(' ')
+		writeLogfmtKey(buffer, MessageKey)
+		scratch.Reset()
+		writeMessage(scratch, values, enc.TimeLayout)
+		writeLogfmtValue(buffer, scratch.Bytes())
+	}
+	eachField(values, func(field Field) {
+		if !isHeader(field) {
+			writeField(field)
+		}
+	})
+}
+
+// writeLogfmtKey writes key= quoting the key if needed.
+func writeLogfmtKey(buffer *bytes.Buffer, key string) {
+	if key == "" || needsLogfmtQuotes(key) {
+		buffer.WriteString(strconv.Quote(key))
+	} else {
+		buffer.WriteString(key)
+	}
+	buffer.WriteByte('=')
+}
+
+// writeLogfmtValue writes the value quoting it if needed.
+func writeLogfmtValue(buffer *bytes.Buffer, value []byte) {
+	if needsLogfmtQuotes(string(value)) {
+		buffer.WriteString(strconv.Quote(string(value)))
+	} else {
+		buffer.Write(value)
+	}
+}
+
+// needsLogfmtQuotes tells if s must be quoted.
+func needsLogfmtQuotes(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r == '=' || r == '"' || r == '\\' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Decode implements Decoder, see ParseLogfmt.
+func (enc LogfmtEncoder) Decode(entry string) (string, []interface{}, error) {
+	return ParseLogfmt(entry)
+}
+
+// ParseLogfmt parses a line encoded by LogfmtEncoder.
+// The level is parsed with ParseLevel and kept as is if unknown.
+// The msg value is returned as a string, the other pairs as fields of string values.
+func ParseLogfmt(line string) (level string, values []interface{}, err error) {
+	values = make([]interface{}, 0)
+	for rest := strings.TrimSpace(line); rest != ""; rest = strings.TrimLeftFunc(rest, unicode.IsSpace) {
+		var key, value string
+		key, rest, err = readLogfmtToken(rest)
+		if err != nil {
+			return "", nil, err
+		}
+		if strings.HasPrefix(rest, "=") {
+			value, rest, err = readLogfmtToken(rest[1:])
+			if err != nil {
+				return "", nil, err
+			}
+		}
+		switch key {
+		case LevelKey:
+			level = value
+			if parsedLevel, err := ParseLevel(value); err == nil {
+				level = parsedLevel
+			}
+		case MessageKey:
+			values = append(values, value)
+		default:
+			values = append(values, F(key, value))
+		}
+	}
+	return level, values, nil
+}
+
+// readLogfmtToken reads a key or a value, quoted or not.
+func readLogfmtToken(s string) (token string, rest string, err error) {
+	if !strings.HasPrefix(s, `"`) {
+		end := strings.IndexFunc(s, func(r rune) bool {
+			return r == '=' || unicode.IsSpace(r)
+		})
+		if end < 0 {
+			return s, "", nil
+		}
+		return s[:end], s[end:], nil
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			token, err = strconv.Unquote(s[:i+1])
+			return token, s[i+1:], err
+		}
+	}
+	return "", "", fmt.Errorf("unterminated quoted string in %q", s)
+}
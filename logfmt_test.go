@@ -0,0 +1,72 @@
+package log4g
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogfmtEncoder(t *testing.T) {
+	encode := func(level string, values ...interface{}) string {
+		var buffer bytes.Buffer
+		LogfmtEncoder{}.Encode(&buffer, level, values...)
+		return buffer.String()
+	}
+	date := time.Date(2018, 11, 29, 0, 38, 11, 0, time.UTC)
+	assert.Equal(t, "level=INFO", encode(INFO))
+	assert.Equal(t, `level=INFO time=2018-11-29T00:38:11Z caller=main.go:12 msg="square root 6" n=41`,
+		encode(INFO, "square root", F("n", 41), 6, F(CallerKey, "main.go:12"), F(TimeKey, date)))
+	assert.Equal(t, `level=topic "my key"="a \"quoted\"\nvalue" err="file not found" empty= nil=`,
+		encode("topic", F("my key", "a \"quoted\"\nvalue"), F("err", errors.New("file not found")), F("empty", ""), F("nil", nil)))
+	var buffer bytes.Buffer
+	LogfmtEncoder{TimeLayout: time.Kitchen}.Encode(&buffer, WARN, F(TimeKey, date))
+	assert.Equal(t, "level=WARN time=12:38AM", buffer.String())
+}
+
+func TestParseLogfmt(t *testing.T) {
+	line := `level=WARN time=2018-11-29T00:38:11Z msg="square root 6" "my key"="a \"quoted\"\nvalue" bare`
+	level, values, err := ParseLogfmt(line)
+	assert.Nil(t, err)
+	assert.Equal(t, WARN, level)
+	assert.Equal(t, []interface{}{
+		F(TimeKey, "2018-11-29T00:38:11Z"),
+		"square root 6",
+		F("my key", "a \"quoted\"\nvalue"),
+		F("bare", ""),
+	}, values)
+	var buffer bytes.Buffer
+	LogfmtEncoder{}.Encode(&buffer, level, values...)
+	assert.Equal(t, line+"=", buffer.String())
+	level, _, err = ParseLogfmt("level=topic")
+	assert.Nil(t, err)
+	assert.Equal(t, "topic", level)
+	_, _, err = ParseLogfmt(`level=INFO msg="unterminated`)
+	assert.NotNil(t, err)
+	level, values, err = LogfmtEncoder{}.Decode(`level=INFO msg="is prime" n=41`)
+	assert.Nil(t, err)
+	assert.Equal(t, INFO, level)
+	assert.Equal(t, []interface{}{"is prime", F("n", "41")}, values)
+}
+
+func TestLogfmtFile(t *testing.T) {
+	file, err := ioutil.TempFile("", "log4g")
+	assert.Nil(t, err)
+	file.Close()
+	defer os.Remove(file.Name())
+	fwc := FileWritingContext{
+		Path:          file.Name(),
+		CallDelimiter: "\n",
+		Encoder:       LogfmtEncoder{},
+	}
+	assert.Nil(t, fwc.Init())
+	fwc.Logger(INFO, "is prime", F("n", 41))
+	assert.Nil(t, fwc.Close())
+	content, err := ioutil.ReadFile(file.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, "level=INFO msg=\"is prime\" n=41\n", string(content))
+}
@@ -2,9 +2,12 @@ package log4g
 
 import (
 	"fmt"
+	"path"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/potatomasterrace/catch"
@@ -13,6 +16,23 @@ import (
 // Logger is an abstract logger.
 type Logger func(level string, values ...interface{})
 
+// concatValues returns a new slice holding the values of both slices.
+// Loggers can keep the values they receive so each combinator allocates its own slice.
+func concatValues(first []interface{}, second []interface{}) []interface{} {
+	values := make([]interface{}, len(first)+len(second))
+	copy(values, first)
+	copy(values[len(first):], second)
+	return values
+}
+
+// prependValue returns a new slice starting with value followed by values.
+func prependValue(value interface{}, values []interface{}) []interface{} {
+	prepended := make([]interface{}, len(values)+1)
+	prepended[0] = value
+	copy(prepended[1:], values)
+	return prepended
+}
+
 // MockLogger returns a mock of a logger.
 func T() Logger {
 	return func(level string, values ...interface{}) {
@@ -20,26 +40,113 @@ func T() Logger {
 	}
 }
 
+// formattedTime is a time formatted by PrependTime.
+type formattedTime struct {
+	unix      int64
+	formatted interface{}
+}
+
+// lastFormattedTime caches the *formattedTime of the current second.
+var lastFormattedTime atomic.Value
+
+// formatNow returns the current time formatted with time.RFC1123.
+// The formatted time is computed once per second.
+func formatNow() interface{} {
+	now := time.Now()
+	last, ok := lastFormattedTime.Load().(*formattedTime)
+	if ok && last.unix == now.Unix() {
+		return last.formatted
+	}
+	last = &formattedTime{
+		unix:      now.Unix(),
+		formatted: now.Format(time.RFC1123),
+	}
+	lastFormattedTime.Store(last)
+	return last.formatted
+}
+
 // PrependTime prepends the time of calls to the logger.
 func (logger Logger) PrependTime() Logger {
 	return func(level string, values ...interface{}) {
-		time := time.Now().Format(time.RFC1123)
-		logger(level, append([]interface{}{time}, values...)...)
+		logger(level, prependValue(formatNow(), values)...)
+	}
+}
+
+const (
+	// UnixMillis is the TimeFormat layout of milliseconds since the epoch.
+	UnixMillis = "unixmillis"
+	// Elapsed is the TimeFormat layout of the duration since the logger creation.
+	Elapsed = "elapsed"
+)
+
+// TimeFormat configures the time prepended by Timestamp.
+type TimeFormat struct {
+	// Layout is a time layout, UnixMillis or Elapsed.
+	// Defaults to time.RFC1123 if field empty.
+	Layout string
+	// UTC converts the time to UTC instead of the local zone.
+	UTC bool
+	// Function called to get the current time.
+	// Defaults to time.Now if field empty.
+	Clock func() time.Time
+	// Key of the time field prepended to calls holding fields.
+	// Defaults to TimeKey if field empty.
+	Key string
+}
+
+// Timestamp prepends the time of calls to the logger.
+// Calls holding fields get a time.Time field, or a time.Duration field for Elapsed,
+// instead of the formatted time.
+func (logger Logger) Timestamp(format TimeFormat) Logger {
+	if format.Layout == "" {
+		format.Layout = time.RFC1123
+	}
+	if format.Clock == nil {
+		format.Clock = time.Now
+	}
+	if format.Key == "" {
+		format.Key = TimeKey
+	}
+	// time.Now carries a monotonic clock reading, so elapsed times aren't affected by clock changes.
+	start := format.Clock()
+	return func(level string, values ...interface{}) {
+		now := format.Clock()
+		// UTC strips the monotonic clock reading, so the elapsed time is computed first
+		elapsed := now.Sub(start)
+		if format.UTC {
+			now = now.UTC()
+		}
+		structured := hasField(values)
+		var timestamp interface{}
+		switch {
+		case format.Layout == Elapsed && structured:
+			timestamp = Duration(format.Key, elapsed)
+		case format.Layout == Elapsed:
+			timestamp = elapsed.String()
+		case structured:
+			timestamp = Time(format.Key, now)
+		case format.Layout == UnixMillis:
+			timestamp = strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10)
+		default:
+			timestamp = now.Format(format.Layout)
+		}
+		logger(level, prependValue(timestamp, values)...)
 	}
 }
 
 // PrependGoRoutines prepends the current number of running goroutines.
 func (logger Logger) PrependGoRoutines() Logger {
 	return func(level string, values ...interface{}) {
-		msg := fmt.Sprint("[ Go routines : ", runtime.NumGoroutine(), " ]")
-		logger(level, append([]interface{}{msg}, values...)...)
+		msg := "[ Go routines : " + strconv.Itoa(runtime.NumGoroutine()) + " ]"
+		logger(level, prependValue(msg, values)...)
 	}
 }
 
 // Prepend the values of loggint to the logger.
 func (logger Logger) Prepend(prependValues ...interface{}) Logger {
+	prependValues = concatValues(prependValues, nil)
 	return func(level string, values ...interface{}) {
-		logger(level, append(prependValues, values...)...)
+		logger(level, concatValues(prependValues, values)...)
 	}
 }
 
@@ -56,48 +163,64 @@ func (logger Logger) PrependString(prependedMsgs ...string) Logger {
 // The function name is prepended automatically.
 // Provide the arguments to log as parameters.
 func (logger Logger) FunCall(args ...interface{}) Logger {
-	// get Caller name pointer
-	fpcs := make([]uintptr, 1)
-	runtime.Callers(2, fpcs)
-	// get Caller func
-	fun := runtime.FuncForPC(fpcs[0])
+	return logger.FunCallSkip(1, args...)
+}
+
+// FunCallSkip is FunCall skipping skip stack frames,
+// for helpers declaring the function call of their caller.
+// FunCallSkip(0) declares the function calling FunCallSkip.
+func (logger Logger) FunCallSkip(skip int, args ...interface{}) Logger {
+	frame := callerFrame(skip)
 	// format func name
-	funcName := fun.Name()
-	// Removing filePath
-	if strings.Contains(funcName, ".") {
-		if nbPoint := strings.Count(funcName, "."); nbPoint > 0 {
-			parts := strings.Split(funcName, ".")[1:]
-			funcName = strings.Join(parts, ".")
-		}
-	}
+	funcName := shortFuncName(frame.Function)
 	header := fmt.Sprintf(" -> %s %v : ", funcName, args)
 	return logger.Prepend(header)
 }
 
-// DetailedFunCall Provide the arguments to log as parameters.
-func (logger Logger) DetailedFunCall(args ...interface{}) Logger {
-	// get Caller name pointer
+// callerFrame returns the frame of the caller of the function calling callerFrame,
+// skipping skip frames.
+func callerFrame(skip int) runtime.Frame {
+	// get Caller pointer
 	fpcs := make([]uintptr, 1)
-	runtime.Callers(2, fpcs)
-	// get Caller func
-	fun := runtime.FuncForPC(fpcs[0])
-	// format func name
-	funcName := fun.Name()
+	runtime.Callers(skip+3, fpcs)
+	// get Caller frame
+	frame, _ := runtime.CallersFrames(fpcs).Next()
+	return frame
+}
+
+// shortFuncName removes the package path from a function name.
+func shortFuncName(funcName string) string {
 	// Removing filePath
-	if strings.Contains(funcName, ".") {
-		if nbPoint := strings.Count(funcName, "."); nbPoint > 0 {
-			parts := strings.Split(funcName, ".")[1:]
-			funcName = strings.Join(parts, ".")
-		}
+	if i := strings.LastIndex(funcName, "/"); i >= 0 {
+		funcName = funcName[i+1:]
 	}
-	header := fmt.Sprintf(" -> %s %v %d : ", funcName, args, fun.Entry())
+	// Removing package name
+	if i := strings.Index(funcName, "."); i >= 0 {
+		funcName = funcName[i+1:]
+	}
+	return funcName
+}
+
+// DetailedFunCall Provide the arguments to log as parameters.
+// The file:line of the call is prepended too.
+func (logger Logger) DetailedFunCall(args ...interface{}) Logger {
+	return logger.DetailedFunCallSkip(1, args...)
+}
+
+// DetailedFunCallSkip is DetailedFunCall skipping skip stack frames.
+func (logger Logger) DetailedFunCallSkip(skip int, args ...interface{}) Logger {
+	frame := callerFrame(skip)
+	// format func name
+	funcName := shortFuncName(frame.Function)
+	header := fmt.Sprintf(" -> %s %v %s:%d : ", funcName, args, path.Base(frame.File), frame.Line)
 	return logger.Prepend(header)
 }
 
 // Append values to the logger.
 func (logger Logger) Append(appendedValues ...interface{}) Logger {
+	appendedValues = concatValues(appendedValues, nil)
 	return func(level string, values ...interface{}) {
-		logger(level, append(values, appendedValues...)...)
+		logger(level, concatValues(values, appendedValues)...)
 	}
 }
 
@@ -56,6 +56,23 @@ func TestAsync(t *testing.T) {
 	Logger(nil).Async(panicHandler)
 	Logger(nil).Async(nil)
 }
+func TestLoggerKeepsValues(t *testing.T) {
+	logger, logs := NewInMemoryLogger()
+	kept := make([][]interface{}, 0)
+	chain := Logger(func(level string, values ...interface{}) {
+		kept = append(kept, values)
+		logger(level, values...)
+	}).Prepend("prepend").Append("append").AddCaller(CallerFormat{}).Span(NewTrace()).PrependTime()
+	chain(INFO, "first")
+	chain(INFO, "second")
+	assert.Equal(t, 2, len(kept))
+	for i, call := range *logs {
+		assert.Equal(t, call[1:], kept[i])
+	}
+	assert.Contains(t, kept[0], "first")
+	assert.Contains(t, kept[1], "second")
+}
+
 func TestWithLock(t *testing.T) {
 	start := time.Now()
 	waitime := 100
@@ -65,10 +82,10 @@ func TestWithLock(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(waitime)
 	for i := 0; i < waitime; i++ {
-		go func() {
+		go func(i int) {
 			defer wg.Done()
 			logger(INFO, i)
-		}()
+		}(i)
 	}
 	wg.Wait()
 	fmt.Println(time.Now().Sub(start))
@@ -83,3 +100,49 @@ func TestLoggerPanicHandle(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Nil(t, Logger)
 }
+
+func TestTimestamp(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		paris = time.FixedZone("CET", 3600)
+	}
+	now := time.Date(2018, 11, 29, 0, 38, 11, 123456789, paris)
+	clock := func() time.Time { return now }
+	logger, buffer := NewInMemoryLogger()
+	logger.Timestamp(TimeFormat{Clock: clock})(INFO, "rfc1123")
+	logger.Timestamp(TimeFormat{Clock: clock, UTC: true, Layout: time.RFC3339Nano})(INFO, "utc")
+	logger.Timestamp(TimeFormat{Clock: clock, Layout: UnixMillis})(INFO, "millis")
+	logger.Timestamp(TimeFormat{Clock: clock, UTC: true})(INFO, "structured", Int("n", 41))
+	elapsed := logger.Timestamp(TimeFormat{Clock: clock, Layout: Elapsed, Key: "elapsed"})
+	now = now.Add(1500 * time.Millisecond)
+	elapsed(INFO, "elapsed")
+	elapsed(INFO, "structured", Int("n", 41))
+	assert.Equal(t, InMemoryLogs{
+		{INFO, "Thu, 29 Nov 2018 00:38:11 CET", "rfc1123"},
+		{INFO, "2018-11-28T23:38:11.123456789Z", "utc"},
+		{INFO, "1543448291123", "millis"},
+		{INFO, Time(TimeKey, now.Add(-1500*time.Millisecond).UTC()), "structured", Int("n", 41)},
+		{INFO, "1.5s", "elapsed"},
+		{INFO, Duration("elapsed", 1500*time.Millisecond), "structured", Int("n", 41)},
+	}, *buffer)
+	t.Run("monotonic", func(t *testing.T) {
+		logger, buffer := NewInMemoryLogger()
+		logger = logger.Timestamp(TimeFormat{Layout: Elapsed})
+		logger(INFO, Int("n", 1))
+		assert.True(t, (*buffer)[0][1].(Field).Value().(time.Duration) >= 0)
+	})
+	t.Run("utc elapsed", func(t *testing.T) {
+		// time.Now carries the monotonic reading that UTC strips
+		start := time.Now()
+		now := start
+		logger, buffer := NewInMemoryLogger()
+		logger = logger.Timestamp(TimeFormat{Layout: Elapsed, UTC: true, Clock: func() time.Time { return now }})
+		now = start.Add(1500 * time.Millisecond)
+		logger(INFO, "elapsed")
+		logger(INFO, Int("n", 1))
+		assert.Equal(t, InMemoryLogs{
+			{INFO, "1.5s", "elapsed"},
+			{INFO, Duration(TimeKey, 1500*time.Millisecond), Int("n", 1)},
+		}, *buffer)
+	})
+}
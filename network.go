@@ -0,0 +1,438 @@
+package log4g
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// NetworkStats are the delivery statistics of a NetworkContext.
+type NetworkStats struct {
+	// Delivered is the number of records sent, replayed ones included.
+	Delivered uint64 `json:"delivered"`
+	// Spooled is the number of records written to the disk spool.
+	Spooled uint64 `json:"spooled"`
+	// Replayed is the number of records sent from the disk spool.
+	Replayed uint64 `json:"replayed"`
+	// Dropped is the number of records lost.
+	Dropped uint64 `json:"dropped"`
+	// Reconnects is the number of connections after the first one.
+	Reconnects uint64 `json:"reconnects"`
+}
+
+// networkRecord is a logger call waiting to be sent.
+type networkRecord struct {
+	level  string
+	values []interface{}
+}
+
+// NetworkContext stores the data for sending logged values to a TCP or UDP server,
+// one encoded record per line.
+// Calls are buffered in memory and sent in the background, reconnecting with
+// exponential backoff. When the buffer is full the calls spill to a disk spool,
+// replayed in order once the buffer is drained.
+// A record written to a connection which then breaks may be lost or sent twice.
+type NetworkContext struct {
+	// stats is first for 64-bit atomic alignment on 32-bit platforms.
+	stats NetworkStats
+	Logger
+	// Network is "tcp" or "udp".
+	// Defaults to "tcp" if field empty.
+	Network string
+	// Address of the server, host:port.
+	Address string
+	// Encoder encodes the records, it must not write newlines.
+	// Defaults to a JSONEncoder (JSON Lines) if field empty.
+	Encoder Encoder
+	// BufferSize is the max number of records buffered in memory.
+	// Defaults to 1024 if field empty.
+	BufferSize int
+	// SpoolPath is the path of the disk spool.
+	// Calls are dropped when the buffer is full if field empty.
+	SpoolPath string
+	// Backoff is the wait before the first reconnection, doubled on each failure.
+	// Defaults to 100 milliseconds if field empty.
+	Backoff time.Duration
+	// MaxBackoff caps Backoff.
+	// Defaults to 30 seconds if field empty.
+	MaxBackoff time.Duration
+	// Timeout bounds the connections and the writes.
+	// Defaults to 5 seconds if field empty.
+	Timeout time.Duration
+	conn    net.Conn
+	// ring is the memory buffer, a circular queue of count records from head.
+	ring  []networkRecord
+	head  int
+	count int
+	// spool receives the calls while spooling is true, spoolReader replays it.
+	spool       *FileWritingContext
+	spooling    bool
+	spoolReader *bufio.Reader
+	spoolFile   *os.File
+	connected   bool
+	closing     bool
+	lock        sync.Mutex
+	wake        *sync.Cond
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// Init starts sending.
+// An existing non empty spool, left by a previous run, is replayed first.
+func (nc *NetworkContext) Init() error {
+	if nc.Address == "" {
+		return fmt.Errorf("missing network address")
+	}
+	if nc.Network == "" {
+		nc.Network = "tcp"
+	}
+	if nc.Encoder == nil {
+		nc.Encoder = JSONEncoder{}
+	}
+	if nc.BufferSize <= 0 {
+		nc.BufferSize = 1024
+	}
+	if nc.Backoff <= 0 {
+		nc.Backoff = 100 * time.Millisecond
+	}
+	if nc.MaxBackoff <= 0 {
+		nc.MaxBackoff = 30 * time.Second
+	}
+	if nc.Timeout <= 0 {
+		nc.Timeout = 5 * time.Second
+	}
+	nc.ring = make([]networkRecord, nc.BufferSize)
+	nc.head, nc.count = 0, 0
+	nc.closing = false
+	nc.wake = sync.NewCond(&nc.lock)
+	nc.stop = make(chan struct{})
+	nc.done = make(chan struct{})
+	if nc.SpoolPath != "" {
+		info, err := os.Stat(nc.SpoolPath)
+		if err == nil && info.Size() > 0 {
+			err = nc.openSpool()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	nc.Logger = func(level string, values ...interface{}) {
+		nc.lock.Lock()
+		defer nc.lock.Unlock()
+		if nc.closing {
+			atomic.AddUint64(&nc.stats.Dropped, 1)
+			return
+		}
+		if nc.spooling || nc.count == len(nc.ring) {
+			nc.spill(level, values)
+			return
+		}
+		nc.ring[(nc.head+nc.count)%len(nc.ring)] = networkRecord{
+			level:  level,
+			values: append([]interface{}(nil), values...),
+		}
+		nc.count++
+		nc.wake.Signal()
+	}
+	go nc.run()
+	return nil
+}
+
+// spill writes a call to the disk spool, or drops it without spool.
+// Must be called with the lock held.
+func (nc *NetworkContext) spill(level string, values []interface{}) {
+	if nc.SpoolPath == "" {
+		atomic.AddUint64(&nc.stats.Dropped, 1)
+		return
+	}
+	if !nc.spooling {
+		err := nc.openSpool()
+		if err != nil {
+			panic(err)
+		}
+	}
+	nc.spool.Logger(level, values...)
+	atomic.AddUint64(&nc.stats.Spooled, 1)
+	nc.wake.Signal()
+}
+
+// openSpool opens the spool for writing and for replaying.
+// Must be called with the lock held.
+func (nc *NetworkContext) openSpool() error {
+	spool := &FileWritingContext{
+		Path:          nc.SpoolPath,
+		CallDelimiter: "\n",
+		Encoder:       nc.Encoder,
+	}
+	err := spool.Init()
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(nc.SpoolPath)
+	if err != nil {
+		spool.Close()
+		return err
+	}
+	nc.spool = spool
+	nc.spoolFile = file
+	nc.spoolReader = bufio.NewReader(file)
+	nc.spooling = true
+	return nil
+}
+
+// closeSpool closes the spool, removing it if replayed.
+// Must be called with the lock held.
+func (nc *NetworkContext) closeSpool(remove bool) {
+	nc.spool.Close()
+	nc.spoolFile.Close()
+	if remove {
+		os.Remove(nc.SpoolPath)
+	}
+	nc.spool, nc.spoolFile, nc.spoolReader = nil, nil, nil
+	nc.spooling = false
+}
+
+// next writes the next line to send, the oldest buffered record first and then the spool.
+// size is the number of bytes read from the spool, 0 for a buffered record.
+// ok is false when closing with nothing left to send.
+func (nc *NetworkContext) next(line *bytes.Buffer) (size int, ok bool) {
+	nc.lock.Lock()
+	defer nc.lock.Unlock()
+	line.Reset()
+	for {
+		if nc.count > 0 {
+			record := nc.ring[nc.head]
+			nc.Encoder.Encode(line, record.level, record.values...)
+			line.WriteByte('\n')
+			return 0, true
+		}
+		if nc.spooling {
+			spooled, err := nc.spoolReader.ReadBytes('\n')
+			if len(spooled) > 0 {
+				line.Write(spooled)
+				if spooled[len(spooled)-1] != '\n' {
+					// truncated by a crash of a previous run
+					line.WriteByte('\n')
+				}
+				return len(spooled), true
+			}
+			if err == io.EOF {
+				nc.closeSpool(true)
+				continue
+			}
+			if err != nil {
+				panic(err)
+			}
+		}
+		if nc.closing {
+			return 0, false
+		}
+		nc.wake.Wait()
+	}
+}
+
+// ack removes the sent line from the buffer.
+func (nc *NetworkContext) ack(fromSpool bool) {
+	atomic.AddUint64(&nc.stats.Delivered, 1)
+	if fromSpool {
+		atomic.AddUint64(&nc.stats.Replayed, 1)
+		return
+	}
+	nc.lock.Lock()
+	defer nc.lock.Unlock()
+	nc.ring[nc.head] = networkRecord{}
+	nc.head = (nc.head + 1) % len(nc.ring)
+	nc.count--
+}
+
+// send writes a line, connecting first if needed.
+// The connection is closed on failure.
+func (nc *NetworkContext) send(line []byte) error {
+	if nc.conn == nil {
+		conn, err := net.DialTimeout(nc.Network, nc.Address, nc.Timeout)
+		if err != nil {
+			return err
+		}
+		nc.lock.Lock()
+		if nc.connected {
+			atomic.AddUint64(&nc.stats.Reconnects, 1)
+		}
+		nc.conn = conn
+		nc.connected = true
+		nc.lock.Unlock()
+	}
+	nc.conn.SetWriteDeadline(time.Now().Add(nc.Timeout))
+	_, err := nc.conn.Write(line)
+	if err != nil {
+		nc.lock.Lock()
+		nc.conn.Close()
+		nc.conn = nil
+		nc.lock.Unlock()
+	}
+	return err
+}
+
+// run sends the lines until closed, backing off on failures.
+func (nc *NetworkContext) run() {
+	defer close(nc.done)
+	backoff := nc.Backoff
+	line := &bytes.Buffer{}
+	for {
+		size, ok := nc.next(line)
+		if !ok {
+			return
+		}
+		err := nc.send(line.Bytes())
+		if err == nil {
+			backoff = nc.Backoff
+			nc.ack(size > 0)
+			continue
+		}
+		nc.lock.Lock()
+		if size > 0 {
+			// the spooled line is read again after the backoff
+			nc.rewindSpool(size)
+		}
+		closing := nc.closing
+		nc.lock.Unlock()
+		if closing {
+			return
+		}
+		select {
+		case <-nc.stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > nc.MaxBackoff {
+			backoff = nc.MaxBackoff
+		}
+	}
+}
+
+// rewindSpool moves the spool reader back by n bytes.
+// Must be called with the lock held.
+func (nc *NetworkContext) rewindSpool(n int) {
+	if !nc.spooling {
+		return
+	}
+	offset, err := nc.spoolFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		panic(err)
+	}
+	offset -= int64(nc.spoolReader.Buffered() + n)
+	_, err = nc.spoolFile.Seek(offset, io.SeekStart)
+	if err != nil {
+		panic(err)
+	}
+	nc.spoolReader.Reset(nc.spoolFile)
+}
+
+// Close sends the buffered records if the server is reachable and stops the sink.
+// Records left are saved in the spool, ahead of the spooled ones, or dropped without spool.
+// Later calls are dropped.
+func (nc *NetworkContext) Close() error {
+	nc.lock.Lock()
+	if nc.done == nil || nc.closing {
+		nc.lock.Unlock()
+		return fmt.Errorf("trying to close already closed network sink %s", nc.Address)
+	}
+	nc.closing = true
+	nc.wake.Broadcast()
+	nc.lock.Unlock()
+	select {
+	case <-nc.done:
+	case <-time.After(nc.Timeout):
+		close(nc.stop)
+		<-nc.done
+	}
+	nc.lock.Lock()
+	defer nc.lock.Unlock()
+	var err error
+	if nc.count > 0 || nc.spooling {
+		err = nc.saveBacklog()
+	}
+	if nc.conn != nil {
+		nc.conn.Close()
+		nc.conn = nil
+	}
+	return err
+}
+
+// saveBacklog writes the buffered records to a new spool followed by the records
+// not replayed from the current spool.
+// Must be called with the lock held.
+func (nc *NetworkContext) saveBacklog() error {
+	if nc.SpoolPath == "" {
+		atomic.AddUint64(&nc.stats.Dropped, uint64(nc.count))
+		nc.count = 0
+		return nil
+	}
+	saved := &FileWritingContext{
+		Path:          nc.SpoolPath + ".tmp",
+		CallDelimiter: "\n",
+		Encoder:       nc.Encoder,
+	}
+	err := saved.Init()
+	if err != nil {
+		return err
+	}
+	err = saved.File.Truncate(0)
+	for ; err == nil && nc.count > 0; nc.count-- {
+		record := nc.ring[nc.head]
+		err = saved.NoPanic(record.level, record.values...)
+		nc.head = (nc.head + 1) % len(nc.ring)
+		atomic.AddUint64(&nc.stats.Spooled, 1)
+	}
+	if err == nil && nc.spooling {
+		_, err = io.Copy(saved.File, nc.spoolReader)
+	}
+	closeErr := saved.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if nc.spooling {
+		nc.closeSpool(false)
+	}
+	if err != nil {
+		atomic.AddUint64(&nc.stats.Dropped, uint64(nc.count))
+		nc.count = 0
+		return err
+	}
+	return os.Rename(saved.Path, nc.SpoolPath)
+}
+
+// Stats returns the delivery statistics.
+func (nc *NetworkContext) Stats() NetworkStats {
+	return NetworkStats{
+		Delivered:  atomic.LoadUint64(&nc.stats.Delivered),
+		Spooled:    atomic.LoadUint64(&nc.stats.Spooled),
+		Replayed:   atomic.LoadUint64(&nc.stats.Replayed),
+		Dropped:    atomic.LoadUint64(&nc.stats.Dropped),
+		Reconnects: atomic.LoadUint64(&nc.stats.Reconnects),
+	}
+}
+
+// Health reports the connection and the spool as open files,
+// the records buffered in memory and the dropped records.
+func (nc *NetworkContext) Health() SinkHealth {
+	nc.lock.Lock()
+	defer nc.lock.Unlock()
+	health := SinkHealth{
+		QueueDepth: nc.count,
+		Dropped:    atomic.LoadUint64(&nc.stats.Dropped),
+	}
+	if nc.conn != nil {
+		health.OpenFiles++
+	}
+	if nc.spooling {
+		health.OpenFiles++
+	}
+	return health
+}
@@ -0,0 +1,176 @@
+package log4g
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lineServer accepts connections on address and sends the received lines.
+func lineServer(t *testing.T, address string) (net.Listener, chan string) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := make(chan string, 64)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+			}(conn)
+		}
+	}()
+	return listener, lines
+}
+
+// receive returns the next n lines.
+func receive(t *testing.T, lines chan string, n int) []string {
+	received := make([]string, 0, n)
+	deadline := time.After(5 * time.Second)
+	for len(received) < n {
+		select {
+		case line := <-lines:
+			received = append(received, line)
+		case <-deadline:
+			t.Fatalf("received %d lines out of %d: %v", len(received), n, received)
+		}
+	}
+	return received
+}
+
+// unusedAddress returns a local address nothing listens on.
+func unusedAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestNetworkContext(t *testing.T) {
+	listener, lines := lineServer(t, "127.0.0.1:0")
+	defer listener.Close()
+	nc := NetworkContext{Address: listener.Addr().String()}
+	assert.Nil(t, nc.Init())
+	nc.Logger(INFO, "is prime", Int("n", 41))
+	nc.Logger(ERROR, "failed")
+	assert.Equal(t, []string{
+		`{"level":"INFO","msg":"is prime","n":41}`,
+		`{"level":"ERROR","msg":"failed"}`,
+	}, receive(t, lines, 2))
+	assert.Nil(t, nc.Close())
+	assert.NotNil(t, nc.Close())
+	nc.Logger(INFO, "closed")
+	assert.Equal(t, NetworkStats{Delivered: 2, Dropped: 1}, nc.Stats())
+}
+
+func TestNetworkContextSpool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "network")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	address := unusedAddress(t)
+	nc := NetworkContext{
+		Address:    address,
+		Encoder:    LogfmtEncoder{},
+		BufferSize: 2,
+		SpoolPath:  filepath.Join(dir, "spool"),
+		Backoff:    time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+	}
+	assert.Nil(t, nc.Init())
+	for i := 0; i < 5; i++ {
+		nc.Logger(INFO, "call", i)
+	}
+	health := nc.Health()
+	assert.Equal(t, 2, health.QueueDepth)
+	assert.Equal(t, 1, health.OpenFiles)
+	spool, err := ioutil.ReadFile(nc.SpoolPath)
+	assert.Nil(t, err)
+	assert.Equal(t, "level=INFO msg=\"call 2\"\nlevel=INFO msg=\"call 3\"\nlevel=INFO msg=\"call 4\"\n", string(spool))
+
+	listener, lines := lineServer(t, address)
+	defer listener.Close()
+	assert.Equal(t, []string{
+		`level=INFO msg="call 0"`,
+		`level=INFO msg="call 1"`,
+		`level=INFO msg="call 2"`,
+		`level=INFO msg="call 3"`,
+		`level=INFO msg="call 4"`,
+	}, receive(t, lines, 5))
+	nc.Logger(INFO, "call", 5)
+	assert.Equal(t, []string{`level=INFO msg="call 5"`}, receive(t, lines, 1))
+	assert.Nil(t, nc.Close())
+	_, err = os.Stat(nc.SpoolPath)
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, NetworkStats{Delivered: 6, Spooled: 3, Replayed: 3}, nc.Stats())
+}
+
+func TestNetworkContextCloseSavesBacklog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "network")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	address := unusedAddress(t)
+	nc := NetworkContext{
+		Address:    address,
+		Encoder:    LogfmtEncoder{},
+		BufferSize: 2,
+		SpoolPath:  filepath.Join(dir, "spool"),
+		Backoff:    time.Millisecond,
+		Timeout:    100 * time.Millisecond,
+	}
+	assert.Nil(t, nc.Init())
+	for i := 0; i < 4; i++ {
+		nc.Logger(WARN, "call", i)
+	}
+	assert.Nil(t, nc.Close())
+	spool, err := ioutil.ReadFile(nc.SpoolPath)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, strings.Count(string(spool), "\n"))
+	assert.True(t, strings.HasPrefix(string(spool), "level=WARN msg=\"call 0\"\n"))
+
+	listener, lines := lineServer(t, address)
+	defer listener.Close()
+	replayed := NetworkContext{Address: address, SpoolPath: nc.SpoolPath}
+	assert.Nil(t, replayed.Init())
+	replayed.Logger(INFO, "new")
+	assert.Equal(t, []string{
+		`level=WARN msg="call 0"`,
+		`level=WARN msg="call 1"`,
+		`level=WARN msg="call 2"`,
+		`level=WARN msg="call 3"`,
+		`{"level":"INFO","msg":"new"}`,
+	}, receive(t, lines, 5))
+	assert.Nil(t, replayed.Close())
+}
+
+func TestNetworkContextDrop(t *testing.T) {
+	nc := NetworkContext{
+		Address:    unusedAddress(t),
+		BufferSize: 1,
+		Backoff:    time.Millisecond,
+		Timeout:    100 * time.Millisecond,
+	}
+	assert.Nil(t, nc.Init())
+	nc.Logger(INFO, "kept")
+	nc.Logger(INFO, "dropped")
+	nc.Logger(INFO, "dropped")
+	assert.Equal(t, SinkHealth{QueueDepth: 1, Dropped: 2}, nc.Health())
+	assert.Nil(t, nc.Close())
+	assert.Equal(t, uint64(3), nc.Stats().Dropped)
+}
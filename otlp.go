@@ -0,0 +1,310 @@
+package log4g
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// otlpSeverities maps the levels to the OpenTelemetry severity numbers.
+var otlpSeverities = map[string]int{
+	FATAL: 21,
+	ERROR: 17,
+	WARN:  13,
+	INFO:  9,
+	DEBUG: 5,
+	TRACE: 1,
+	ALL:   1,
+}
+
+// otlpAnyValue is an OTLP AnyValue.
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// otlpKeyValue is an OTLP KeyValue.
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+// otlpLogRecord is an OTLP LogRecord.
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano,omitempty"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber,omitempty"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+	TraceID              string         `json:"traceId,omitempty"`
+	SpanID               string         `json:"spanId,omitempty"`
+}
+
+// otlpScope is an OTLP InstrumentationScope.
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+// otlpScopeLogs is an OTLP ScopeLogs.
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+// otlpResource is an OTLP Resource.
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+// otlpResourceLogs is an OTLP ResourceLogs.
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+// otlpLogsRequest is an OTLP ExportLogsServiceRequest.
+type otlpLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+// newOTLPAnyValue converts a value to an OTLP AnyValue.
+func newOTLPAnyValue(value interface{}) otlpAnyValue {
+	switch value := value.(type) {
+	case bool:
+		return otlpAnyValue{BoolValue: &value}
+	case int:
+		intValue := strconv.Itoa(value)
+		return otlpAnyValue{IntValue: &intValue}
+	case int64:
+		intValue := strconv.FormatInt(value, 10)
+		return otlpAnyValue{IntValue: &intValue}
+	case float64:
+		if !math.IsInf(value, 0) && !math.IsNaN(value) {
+			return otlpAnyValue{DoubleValue: &value}
+		}
+	}
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+	writeValue(buffer, value, "")
+	stringValue := buffer.String()
+	return otlpAnyValue{StringValue: &stringValue}
+}
+
+// newOTLPLogRecord converts a logger call to an OTLP LogRecord.
+func newOTLPLogRecord(now time.Time, level string, values []interface{}) otlpLogRecord {
+	record := otlpLogRecord{
+		ObservedTimeUnixNano: strconv.FormatInt(now.UnixNano(), 10),
+		SeverityNumber:       otlpSeverities[level],
+		SeverityText:         LevelName(level),
+	}
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+	writeMessage(buffer, values, "")
+	body := buffer.String()
+	record.Body.StringValue = &body
+	eachField(values, func(field Field) {
+		value := field.Value()
+		switch field.Key {
+		case TimeKey:
+			if t, ok := value.(time.Time); ok {
+				record.TimeUnixNano = strconv.FormatInt(t.UnixNano(), 10)
+				return
+			}
+		case TraceIDKey:
+			record.TraceID = fmt.Sprint(value)
+			return
+		case SpanIDKey:
+			record.SpanID = fmt.Sprint(value)
+			return
+		}
+		record.Attributes = append(record.Attributes, otlpKeyValue{
+			Key:   field.Key,
+			Value: newOTLPAnyValue(value),
+		})
+	})
+	return record
+}
+
+// OTLPExporter stores the data for exporting logged values to an OpenTelemetry collector
+// with the OTLP/HTTP JSON protocol.
+// Calls are queued and posted by batches in the background.
+type OTLPExporter struct {
+	// dropped is first for 64-bit atomic alignment on 32-bit platforms.
+	dropped uint64
+	Logger
+	// Endpoint is the URL of the collector logs endpoint,
+	// usually http://localhost:4318/v1/logs.
+	Endpoint string
+	// Resource are the resource attributes such as service.name.
+	Resource map[string]string
+	// Headers are added to the export requests.
+	Headers map[string]string
+	// Client posts the export requests.
+	// Defaults to an http.Client with a 10 seconds timeout if field empty.
+	Client *http.Client
+	// BatchSize is the max number of records per request.
+	// Defaults to 512 if field empty.
+	BatchSize int
+	// FlushInterval is the max time a record waits in a batch.
+	// Defaults to 1 second if field empty.
+	FlushInterval time.Duration
+	// QueueSize is the max number of records waiting to be exported,
+	// calls are dropped when the queue is full.
+	// Defaults to 2048 if field empty.
+	QueueSize int
+	// MaxRetries is the number of retries of a failed export.
+	// Defaults to 5 if field empty.
+	MaxRetries int
+	// Backoff is the wait before the first retry, doubled on each retry.
+	// Defaults to 100 milliseconds if field empty.
+	Backoff time.Duration
+	// ErrorHandler is called with the errors of the batches dropped after the retries.
+	ErrorHandler func(error)
+	queue        chan otlpLogRecord
+	done         chan struct{}
+	closed       bool
+	lock         sync.RWMutex
+	pending      int32
+}
+
+// Init starts exporting.
+func (exporter *OTLPExporter) Init() error {
+	if exporter.Endpoint == "" {
+		return fmt.Errorf("missing OTLP endpoint")
+	}
+	if exporter.Client == nil {
+		exporter.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if exporter.BatchSize <= 0 {
+		exporter.BatchSize = 512
+	}
+	if exporter.FlushInterval <= 0 {
+		exporter.FlushInterval = time.Second
+	}
+	if exporter.QueueSize <= 0 {
+		exporter.QueueSize = 2048
+	}
+	if exporter.MaxRetries <= 0 {
+		exporter.MaxRetries = 5
+	}
+	if exporter.Backoff <= 0 {
+		exporter.Backoff = 100 * time.Millisecond
+	}
+	exporter.queue = make(chan otlpLogRecord, exporter.QueueSize)
+	exporter.done = make(chan struct{})
+	exporter.closed = false
+	exporter.Logger = func(level string, values ...interface{}) {
+		record := newOTLPLogRecord(time.Now(), level, values)
+		exporter.lock.RLock()
+		defer exporter.lock.RUnlock()
+		if exporter.closed {
+			atomic.AddUint64(&exporter.dropped, 1)
+			return
+		}
+		select {
+		case exporter.queue <- record:
+		default:
+			atomic.AddUint64(&exporter.dropped, 1)
+		}
+	}
+	go exporter.run()
+	return nil
+}
+
+// run batches the queued records until the exporter is closed.
+func (exporter *OTLPExporter) run() {
+	defer close(exporter.done)
+	ticker := time.NewTicker(exporter.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]otlpLogRecord, 0, exporter.BatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			exporter.export(batch)
+			batch = make([]otlpLogRecord, 0, exporter.BatchSize)
+			atomic.StoreInt32(&exporter.pending, 0)
+		}
+	}
+	for {
+		select {
+		case record, ok := <-exporter.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, record)
+			atomic.StoreInt32(&exporter.pending, int32(len(batch)))
+			if len(batch) >= exporter.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// export posts a batch, retrying on network errors and retryable statuses.
+func (exporter *OTLPExporter) export(batch []otlpLogRecord) {
+	keys := make([]string, 0, len(exporter.Resource))
+	for key := range exporter.Resource {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	resource := otlpResource{Attributes: make([]otlpKeyValue, 0, len(keys))}
+	for _, key := range keys {
+		value := exporter.Resource[key]
+		resource.Attributes = append(resource.Attributes, otlpKeyValue{
+			Key:   key,
+			Value: otlpAnyValue{StringValue: &value},
+		})
+	}
+	body, err := json.Marshal(otlpLogsRequest{
+		ResourceLogs: []otlpResourceLogs{{
+			Resource: resource,
+			ScopeLogs: []otlpScopeLogs{{
+				Scope:      otlpScope{Name: "log4g"},
+				LogRecords: batch,
+			}},
+		}},
+	})
+	if err == nil {
+		err = postJSON(exporter.Client, exporter.Endpoint, exporter.Headers, body, exporter.MaxRetries, exporter.Backoff)
+	}
+	if err != nil {
+		atomic.AddUint64(&exporter.dropped, uint64(len(batch)))
+		if exporter.ErrorHandler != nil {
+			exporter.ErrorHandler(err)
+		}
+	}
+}
+
+// Close exports the queued records and stops the exporter.
+// Later calls are dropped.
+func (exporter *OTLPExporter) Close() error {
+	exporter.lock.Lock()
+	if exporter.queue == nil || exporter.closed {
+		exporter.lock.Unlock()
+		return fmt.Errorf("trying to close already closed OTLP exporter %s", exporter.Endpoint)
+	}
+	exporter.closed = true
+	close(exporter.queue)
+	exporter.lock.Unlock()
+	<-exporter.done
+	return nil
+}
+
+// Health reports the queued and dropped records.
+func (exporter *OTLPExporter) Health() SinkHealth {
+	return SinkHealth{
+		QueueDepth: len(exporter.queue) + int(atomic.LoadInt32(&exporter.pending)),
+		Dropped:    atomic.LoadUint64(&exporter.dropped),
+	}
+}
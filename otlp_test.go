@@ -0,0 +1,112 @@
+package log4g
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// collector is an httptest stand-in for an OTLP collector.
+type collector struct {
+	lock     sync.Mutex
+	requests []otlpLogsRequest
+	failures int
+	headers  []http.Header
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.headers = append(c.headers, r.Header)
+	if c.failures > 0 {
+		c.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var request otlpLogsRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.requests = append(c.requests, request)
+}
+
+func TestOTLPExporter(t *testing.T) {
+	c := &collector{failures: 2}
+	server := httptest.NewServer(c)
+	defer server.Close()
+	exporter := OTLPExporter{
+		Endpoint:      server.URL + "/v1/logs",
+		Resource:      map[string]string{"service.name": "prime", "host.name": "test"},
+		Headers:       map[string]string{"Authorization": "Bearer token"},
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		Backoff:       time.Millisecond,
+	}
+	assert.Nil(t, exporter.Init())
+	date := time.Date(2018, 11, 29, 0, 38, 11, 0, time.UTC)
+	sc, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	logger := exporter.Logger.Span(sc)
+	logger(INFO, "is prime", Int("n", 41), Time(TimeKey, date), Bool("prime", true), Float64("ratio", 0.5), F("factors", []int{1, 41}))
+	logger(ERROR, "failed")
+	logger("topic", "custom")
+	assert.Nil(t, exporter.Close())
+	assert.NotNil(t, exporter.Close())
+	exporter.Logger(INFO, "dropped")
+	assert.Equal(t, SinkHealth{Dropped: 1}, exporter.Health())
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	assert.Equal(t, 4, len(c.headers))
+	assert.Equal(t, "Bearer token", c.headers[0].Get("Authorization"))
+	assert.Equal(t, 2, len(c.requests))
+	resourceLogs := c.requests[0].ResourceLogs[0]
+	assert.Equal(t, "host.name", resourceLogs.Resource.Attributes[0].Key)
+	assert.Equal(t, "prime", *resourceLogs.Resource.Attributes[1].Value.StringValue)
+	assert.Equal(t, "log4g", resourceLogs.ScopeLogs[0].Scope.Name)
+	records := resourceLogs.ScopeLogs[0].LogRecords
+	assert.Equal(t, 2, len(records))
+	assert.Equal(t, 9, records[0].SeverityNumber)
+	assert.Equal(t, "INFO", records[0].SeverityText)
+	assert.Equal(t, "is prime", *records[0].Body.StringValue)
+	assert.Equal(t, "1543451891000000000", records[0].TimeUnixNano)
+	assert.NotEmpty(t, records[0].ObservedTimeUnixNano)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", records[0].TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", records[0].SpanID)
+	attributes := records[0].Attributes
+	assert.Equal(t, 4, len(attributes))
+	assert.Equal(t, "n", attributes[0].Key)
+	assert.Equal(t, "41", *attributes[0].Value.IntValue)
+	assert.True(t, *attributes[1].Value.BoolValue)
+	assert.Equal(t, 0.5, *attributes[2].Value.DoubleValue)
+	assert.Equal(t, "[1 41]", *attributes[3].Value.StringValue)
+	assert.Equal(t, 17, records[1].SeverityNumber)
+	last := c.requests[1].ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	assert.Equal(t, 0, last.SeverityNumber)
+	assert.Equal(t, "topic", last.SeverityText)
+}
+
+func TestOTLPExporterErrors(t *testing.T) {
+	assert.NotNil(t, (&OTLPExporter{}).Init())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	errs := make(chan error, 1)
+	exporter := OTLPExporter{
+		Endpoint:     server.URL,
+		QueueSize:    1,
+		ErrorHandler: func(err error) { errs <- err },
+	}
+	assert.Nil(t, exporter.Init())
+	exporter.Logger(INFO, "rejected")
+	assert.Nil(t, exporter.Close())
+	assert.NotNil(t, <-errs)
+	assert.Equal(t, uint64(1), exporter.Health().Dropped)
+}
@@ -0,0 +1,51 @@
+package log4g
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// postJSON posts a JSON body, retrying with a backoff doubled on each retry
+// on network errors and on the 429, 502, 503 and 504 statuses.
+func postJSON(client *http.Client, endpoint string, headers map[string]string, body []byte, maxRetries int, backoff time.Duration) error {
+	for retry := 0; ; retry++ {
+		retryable, err := post(client, endpoint, headers, body)
+		if err == nil || !retryable || retry >= maxRetries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post sends the request body to the endpoint.
+func post(client *http.Client, endpoint string, headers map[string]string, body []byte) (retryable bool, err error) {
+	request, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(ioutil.Discard, response.Body)
+	response.Body.Close()
+	switch {
+	case response.StatusCode >= 200 && response.StatusCode < 300:
+		return false, nil
+	case response.StatusCode == http.StatusTooManyRequests ||
+		response.StatusCode == http.StatusBadGateway ||
+		response.StatusCode == http.StatusServiceUnavailable ||
+		response.StatusCode == http.StatusGatewayTimeout:
+		return true, fmt.Errorf("post to %s failed: %s", endpoint, response.Status)
+	}
+	return false, fmt.Errorf("post to %s failed: %s", endpoint, response.Status)
+}
@@ -0,0 +1,88 @@
+package log4g
+
+import "sync"
+
+// RingBuffer keeps the last records of a logger in memory,
+// overwriting the oldest ones once full.
+// It is safe for concurrent use.
+type RingBuffer struct {
+	lock        sync.Mutex
+	records     InMemoryLogs
+	head        int
+	count       int
+	total       uint64
+	overwritten uint64
+}
+
+// NewRingBuffer creates a logger keeping its last capacity records in the returned RingBuffer.
+// The values are copied on write. capacity must be positive.
+func NewRingBuffer(capacity int) (Logger, *RingBuffer) {
+	if capacity <= 0 {
+		panic("ring buffer capacity must be positive")
+	}
+	rb := &RingBuffer{records: make(InMemoryLogs, capacity)}
+	return func(level string, values ...interface{}) {
+		data := prependValue(level, values)
+		rb.lock.Lock()
+		defer rb.lock.Unlock()
+		rb.total++
+		if rb.count == len(rb.records) {
+			rb.records[rb.head] = data
+			rb.head = (rb.head + 1) % len(rb.records)
+			rb.overwritten++
+			return
+		}
+		rb.records[(rb.head+rb.count)%len(rb.records)] = data
+		rb.count++
+	}, rb
+}
+
+// snapshot copies the records, oldest first.
+// Must be called with the lock held.
+func (rb *RingBuffer) snapshot() InMemoryLogs {
+	logs := make(InMemoryLogs, rb.count)
+	for i := range logs {
+		logs[i] = rb.records[(rb.head+i)%len(rb.records)]
+	}
+	return logs
+}
+
+// Snapshot returns the records, oldest first.
+func (rb *RingBuffer) Snapshot() InMemoryLogs {
+	rb.lock.Lock()
+	defer rb.lock.Unlock()
+	return rb.snapshot()
+}
+
+// Drain returns the records, oldest first, and empties the buffer.
+func (rb *RingBuffer) Drain() InMemoryLogs {
+	rb.lock.Lock()
+	defer rb.lock.Unlock()
+	logs := rb.snapshot()
+	for i := range rb.records {
+		rb.records[i] = nil
+	}
+	rb.head, rb.count = 0, 0
+	return logs
+}
+
+// Total returns the number of records written.
+func (rb *RingBuffer) Total() uint64 {
+	rb.lock.Lock()
+	defer rb.lock.Unlock()
+	return rb.total
+}
+
+// Overwritten returns the number of records overwritten by newer ones.
+func (rb *RingBuffer) Overwritten() uint64 {
+	rb.lock.Lock()
+	defer rb.lock.Unlock()
+	return rb.overwritten
+}
+
+// Health reports the buffered records and the overwritten ones as dropped.
+func (rb *RingBuffer) Health() SinkHealth {
+	rb.lock.Lock()
+	defer rb.lock.Unlock()
+	return SinkHealth{QueueDepth: rb.count, Dropped: rb.overwritten}
+}
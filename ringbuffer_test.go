@@ -0,0 +1,50 @@
+package log4g
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingBuffer(t *testing.T) {
+	logger, rb := NewRingBuffer(3)
+	assert.Equal(t, InMemoryLogs{}, rb.Snapshot())
+	values := []interface{}{"first"}
+	logger(INFO, values...)
+	values[0] = "changed"
+	logger(WARN, "second")
+	assert.Equal(t, InMemoryLogs{{INFO, "first"}, {WARN, "second"}}, rb.Snapshot())
+	logger(ERROR, "third")
+	logger(DEBUG, "fourth", 4)
+	logger(TRACE, "fifth")
+	assert.Equal(t, InMemoryLogs{{ERROR, "third"}, {DEBUG, "fourth", 4}, {TRACE, "fifth"}}, rb.Snapshot())
+	assert.Equal(t, uint64(5), rb.Total())
+	assert.Equal(t, uint64(2), rb.Overwritten())
+	assert.Equal(t, SinkHealth{QueueDepth: 3, Dropped: 2}, rb.Health())
+	assert.Equal(t, []string{"[ERROR] third ", "[DEBUG] fourth 4 ", "[TRACE] fifth "}, rb.Drain().StringArray(" "))
+	assert.Equal(t, InMemoryLogs{}, rb.Drain())
+	logger(INFO, "after drain")
+	assert.Equal(t, InMemoryLogs{{INFO, "after drain"}}, rb.Snapshot())
+	assert.Equal(t, uint64(6), rb.Total())
+	assert.Panics(t, func() { NewRingBuffer(0) })
+}
+
+func TestRingBufferConcurrentWriters(t *testing.T) {
+	logger, rb := NewRingBuffer(64)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				logger(INFO, i, j)
+				rb.Snapshot()
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, uint64(800), rb.Total())
+	assert.Equal(t, uint64(800-64), rb.Overwritten())
+	assert.Equal(t, 64, len(rb.Drain()))
+}
@@ -0,0 +1,171 @@
+package log4g
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	// TraceIDKey is the key of the trace id field.
+	TraceIDKey = "trace_id"
+	// SpanIDKey is the key of the span id field.
+	SpanIDKey = "span_id"
+	// ParentSpanIDKey is the key of the parent span id field.
+	ParentSpanIDKey = "parent_span_id"
+)
+
+// SpanContext identifies a span of a trace.
+// The ids are compatible with W3C Trace Context.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	// ParentSpanID is zero for root spans.
+	ParentSpanID [8]byte
+	// Flags are the W3C trace flags, 1 for sampled.
+	Flags byte
+}
+
+// randomID fills id with random bytes.
+// Panics if the system random generator fails.
+func randomID(id []byte) {
+	_, err := rand.Read(id)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// NewTrace creates the root span of a new sampled trace.
+func NewTrace() SpanContext {
+	sc := SpanContext{Flags: 1}
+	randomID(sc.TraceID[:])
+	randomID(sc.SpanID[:])
+	return sc
+}
+
+// Child creates a span of the same trace whose parent is sc.
+func (sc SpanContext) Child() SpanContext {
+	child := SpanContext{
+		TraceID:      sc.TraceID,
+		ParentSpanID: sc.SpanID,
+		Flags:        sc.Flags,
+	}
+	randomID(child.SpanID[:])
+	return child
+}
+
+// IsValid tells if the trace id and the span id aren't zero.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent formats the span as a W3C traceparent header.
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%x-%x-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// ParseTraceparent parses a W3C traceparent header.
+// The span id of the header becomes the span id of the returned span,
+// use Child to log under it.
+func ParseTraceparent(traceparent string) (SpanContext, error) {
+	var sc SpanContext
+	var version, flags [1]byte
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 ||
+		decodeLowerHex(version[:], parts[0]) != nil ||
+		decodeLowerHex(sc.TraceID[:], parts[1]) != nil ||
+		decodeLowerHex(sc.SpanID[:], parts[2]) != nil ||
+		decodeLowerHex(flags[:], parts[3]) != nil {
+		return SpanContext{}, fmt.Errorf("malformed traceparent %q", traceparent)
+	}
+	if version[0] == 0xff || version[0] == 0 && len(parts) != 4 {
+		return SpanContext{}, fmt.Errorf("invalid traceparent version in %q", traceparent)
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("zero id in traceparent %q", traceparent)
+	}
+	sc.Flags = flags[0]
+	return sc, nil
+}
+
+// decodeLowerHex decodes s, lowercase hex of exactly len(dst) bytes.
+func decodeLowerHex(dst []byte, s string) error {
+	if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+		return fmt.Errorf("invalid hex %q", s)
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err
+}
+
+// Fields returns the trace id, the span id and, if any, the parent span id fields.
+func (sc SpanContext) Fields() []interface{} {
+	fields := []interface{}{
+		String(TraceIDKey, hex.EncodeToString(sc.TraceID[:])),
+		String(SpanIDKey, hex.EncodeToString(sc.SpanID[:])),
+	}
+	if sc.ParentSpanID != [8]byte{} {
+		fields = append(fields, String(ParentSpanIDKey, hex.EncodeToString(sc.ParentSpanID[:])))
+	}
+	return fields
+}
+
+// spanContextKey is the context key of the SpanContext.
+type spanContextKey struct{}
+
+// ContextWithSpan returns a copy of ctx holding the span.
+func ContextWithSpan(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanFromContext returns the span held by ctx.
+func SpanFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// hasSpan tells if values hold span fields.
+func hasSpan(values []interface{}) bool {
+	for _, value := range values {
+		if field, ok := value.(Field); ok && field.Key == SpanIDKey {
+			return true
+		}
+	}
+	return false
+}
+
+// Span prepends the span id fields to the calls.
+// Calls already holding span fields, from a child span, are left untouched.
+func (logger Logger) Span(sc SpanContext) Logger {
+	fields := sc.Fields()
+	return func(level string, values ...interface{}) {
+		if hasSpan(values) {
+			logger(level, values...)
+			return
+		}
+		logger(level, concatValues(fields, values)...)
+	}
+}
+
+// WithContext prepends the span id fields of the span held by ctx, if any.
+func (logger Logger) WithContext(ctx context.Context) Logger {
+	sc, ok := SpanFromContext(ctx)
+	if !ok {
+		return logger
+	}
+	return logger.Span(sc)
+}
+
+// StartSpan declares the function call of its caller like FunCall in a child span
+// of the span held by ctx, or in a new trace.
+// The returned context holds the child span.
+func (logger Logger) StartSpan(ctx context.Context, args ...interface{}) (context.Context, Logger) {
+	sc, ok := SpanFromContext(ctx)
+	if ok {
+		sc = sc.Child()
+	} else {
+		sc = NewTrace()
+	}
+	return ContextWithSpan(ctx, sc), logger.Span(sc).FunCallSkip(1, args...)
+}
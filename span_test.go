@@ -0,0 +1,77 @@
+package log4g
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceparent(t *testing.T) {
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceparent(traceparent)
+	assert.Nil(t, err)
+	assert.True(t, sc.IsValid())
+	assert.Equal(t, byte(1), sc.Flags)
+	assert.Equal(t, traceparent, sc.Traceparent())
+	child := sc.Child()
+	assert.Equal(t, sc.TraceID, child.TraceID)
+	assert.Equal(t, sc.SpanID, child.ParentSpanID)
+	assert.NotEqual(t, sc.SpanID, child.SpanID)
+	_, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future")
+	assert.Nil(t, err)
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902bz-01",
+	} {
+		_, err = ParseTraceparent(invalid)
+		assert.NotNil(t, err, invalid)
+	}
+	root := NewTrace()
+	assert.True(t, root.IsValid())
+	assert.Equal(t, [8]byte{}, root.ParentSpanID)
+}
+
+func handleRequest(ctx context.Context, logger Logger) {
+	ctx, logger = logger.StartSpan(ctx, "request")
+	logger(INFO, "handling")
+	queryDatabase(ctx, logger)
+}
+
+func queryDatabase(ctx context.Context, logger Logger) {
+	_, logger = logger.StartSpan(ctx, "query")
+	logger(DEBUG, "querying")
+}
+
+func TestSpan(t *testing.T) {
+	sc, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithSpan(context.Background(), sc)
+	logger, buffer := NewInMemoryLogger()
+	logger.WithContext(ctx)(INFO, "incoming")
+	handleRequest(ctx, logger.WithContext(ctx))
+	logger.WithContext(context.Background())(INFO, "no span")
+	lines := buffer.Encode(LogfmtEncoder{})
+	assert.Equal(t, 4, len(lines))
+	assert.Equal(t, "level=INFO msg=incoming trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7", lines[0])
+	requestSpan := (*buffer)[1][2].(Field).Value().(string)
+	assert.Equal(t, "level=INFO msg=\" -> handleRequest [request] :  handling\" trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id="+
+		requestSpan+" parent_span_id=00f067aa0ba902b7", lines[1])
+	assert.Contains(t, lines[2], "trace_id=4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.Contains(t, lines[2], "parent_span_id="+requestSpan)
+	assert.Contains(t, lines[2], "-> queryDatabase [query]")
+	assert.Equal(t, "level=INFO msg=\"no span\"", lines[3])
+	t.Run("new trace", func(t *testing.T) {
+		ctx, _ := logger.StartSpan(context.Background())
+		sc, ok := SpanFromContext(ctx)
+		assert.True(t, ok)
+		assert.True(t, sc.IsValid())
+		assert.Equal(t, [8]byte{}, sc.ParentSpanID)
+	})
+}
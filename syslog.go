@@ -0,0 +1,342 @@
+package log4g
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyslogFormat is the format of the syslog messages.
+type SyslogFormat int
+
+const (
+	// RFC5424 is the structured syslog format.
+	RFC5424 SyslogFormat = iota
+	// RFC3164 is the BSD syslog format, fields are appended to the message.
+	RFC3164
+)
+
+// Syslog facilities.
+// The kern facility, 0, is reserved to the kernel and can't be used.
+const (
+	SyslogUser   = 1
+	SyslogDaemon = 3
+	SyslogAuth   = 4
+	SyslogLocal0 = 16
+	SyslogLocal1 = 17
+	SyslogLocal2 = 18
+	SyslogLocal3 = 19
+	SyslogLocal4 = 20
+	SyslogLocal5 = 21
+	SyslogLocal6 = 22
+	SyslogLocal7 = 23
+)
+
+// syslogSeverities maps the levels to the syslog severities.
+// Unknown levels are logged as informational.
+var syslogSeverities = map[string]int{
+	FATAL: 2,
+	ERROR: 3,
+	WARN:  4,
+	INFO:  6,
+	DEBUG: 7,
+	TRACE: 7,
+	ALL:   7,
+}
+
+// syslogSeverity returns the syslog severity of the level.
+func syslogSeverity(level string) int {
+	severity, ok := syslogSeverities[level]
+	if !ok {
+		return 6
+	}
+	return severity
+}
+
+// localSyslogAddresses are the usual paths of the local syslog socket.
+var localSyslogAddresses = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogContext stores the data for sending logged values to a syslog server.
+type SyslogContext struct {
+	Logger
+	// Network is "udp", "tcp" or "unixgram"/"unix" for a unix socket.
+	// Defaults to the local syslog socket if field empty.
+	Network string
+	// Address of the server, host:port or socket path.
+	Address string
+	// Facility of the messages, from SyslogUser to SyslogLocal7.
+	// Defaults to SyslogUser if field empty.
+	Facility int
+	// AppName identifies the application.
+	// Defaults to the program name if field empty.
+	AppName string
+	// Hostname of the messages.
+	// Defaults to os.Hostname() if field empty.
+	Hostname string
+	Format   SyslogFormat
+	// SDID is the id of the RFC 5424 structured data element holding the fields.
+	// Defaults to "log4g@32473" if field empty.
+	SDID string
+	// Timeout bounds the connections and the writes,
+	// as the calls wait for them.
+	// Defaults to 5 seconds if field empty.
+	Timeout time.Duration
+	// network is the network of conn.
+	network string
+	conn    net.Conn
+	closed  bool
+	lock    sync.Mutex
+}
+
+// dial connects to the server.
+func (sc *SyslogContext) dial() error {
+	if sc.Network != "" {
+		conn, err := net.DialTimeout(sc.Network, sc.Address, sc.Timeout)
+		if err != nil {
+			return err
+		}
+		sc.network, sc.conn = sc.Network, conn
+		return nil
+	}
+	addresses := localSyslogAddresses
+	if sc.Address != "" {
+		addresses = []string{sc.Address}
+	}
+	var err error
+	for _, address := range addresses {
+		for _, network := range []string{"unixgram", "unix"} {
+			var conn net.Conn
+			conn, err = net.DialTimeout(network, address, sc.Timeout)
+			if err == nil {
+				sc.network, sc.conn = network, conn
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unable to connect to local syslog: %v", err)
+}
+
+// Init connects to the syslog server.
+func (sc *SyslogContext) Init() error {
+	if sc.Facility == 0 {
+		sc.Facility = SyslogUser
+	}
+	if sc.Facility < SyslogUser || sc.Facility > SyslogLocal7 {
+		return fmt.Errorf("invalid syslog facility %d", sc.Facility)
+	}
+	if sc.AppName == "" {
+		sc.AppName = filepath.Base(os.Args[0])
+	}
+	if sc.Hostname == "" {
+		sc.Hostname, _ = os.Hostname()
+	}
+	if sc.SDID == "" {
+		sc.SDID = "log4g@32473"
+	}
+	if sc.Timeout <= 0 {
+		sc.Timeout = 5 * time.Second
+	}
+	err := sc.dial()
+	if err != nil {
+		return err
+	}
+	sc.closed = false
+	sc.Logger = func(level string, values ...interface{}) {
+		buffer := getBuffer()
+		defer putBuffer(buffer)
+		sc.encode(buffer, time.Now(), level, values)
+		err := sc.write(buffer.Bytes())
+		if err != nil {
+			panic(err)
+		}
+	}
+	return nil
+}
+
+// write sends a message, reconnecting once on failure.
+func (sc *SyslogContext) write(message []byte) error {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	if sc.closed {
+		return fmt.Errorf("writing to closed syslog connection %s", sc.Address)
+	}
+	var err error
+	if sc.conn != nil {
+		err = sc.send(message)
+		if err == nil {
+			return nil
+		}
+		sc.conn.Close()
+		sc.conn = nil
+	}
+	dialErr := sc.dial()
+	if dialErr != nil {
+		if err == nil {
+			return dialErr
+		}
+		return fmt.Errorf("%v, reconnecting: %v", err, dialErr)
+	}
+	return sc.send(message)
+}
+
+// send writes the message framed for the network of the connection.
+// TCP uses octet counting, unix streams are newline terminated.
+func (sc *SyslogContext) send(message []byte) error {
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+	sc.conn.SetWriteDeadline(time.Now().Add(sc.Timeout))
+	switch {
+	case strings.HasPrefix(sc.network, "tcp"):
+		buffer.WriteString(strconv.Itoa(len(message)))
+		buffer.WriteByte(' ')
+		buffer.Write(message)
+	case sc.network == "unix":
+		buffer.Write(message)
+		buffer.WriteByte('\n')
+	default:
+		_, err := sc.conn.Write(message)
+		return err
+	}
+	_, err := sc.conn.Write(buffer.Bytes())
+	return err
+}
+
+// encode writes the syslog message of the call.
+func (sc *SyslogContext) encode(buffer *bytes.Buffer, now time.Time, level string, values []interface{}) {
+	eachField(values, func(field Field) {
+		if t, ok := field.Value().(time.Time); ok && field.Key == TimeKey {
+			now = t
+		}
+	})
+	buffer.WriteByte('<')
+	buffer.WriteString(strconv.Itoa(sc.Facility*8 + syslogSeverity(level)))
+	buffer.WriteByte('>')
+	if sc.Format == RFC3164 {
+		buffer.WriteString(now.Format(time.Stamp))
+		buffer.WriteByte(' ')
+		buffer.WriteString(sc.Hostname)
+		buffer.WriteByte(' ')
+		buffer.WriteString(sc.AppName)
+		buffer.WriteString("[" + strconv.Itoa(os.Getpid()) + "]: ")
+		writeMessage(buffer, values, "")
+		eachField(values, func(field Field) {
+			if field.Key != TimeKey {
+				buffer.WriteByte(' ')
+				writeValue(buffer, field, "")
+			}
+		})
+		return
+	}
+	buffer.WriteString("1 ")
+	buffer.WriteString(now.Format("2006-01-02T15:04:05.000000Z07:00"))
+	// HOSTNAME, APP-NAME, PROCID and MSGID with their RFC 5424 max lengths
+	headers := []struct {
+		value     string
+		maxLength int
+	}{{sc.Hostname, 255}, {sc.AppName, 48}, {strconv.Itoa(os.Getpid()), 128}, {"-", 32}}
+	for _, header := range headers {
+		buffer.WriteByte(' ')
+		writeSyslogHeader(buffer, header.value, header.maxLength)
+	}
+	buffer.WriteByte(' ')
+	sc.writeStructuredData(buffer, values)
+	if hasMessage(values) {
+		buffer.WriteByte(' ')
+		writeMessage(buffer, values, "")
+	}
+}
+
+// writeStructuredData writes the fields as an RFC 5424 structured data element.
+func (sc *SyslogContext) writeStructuredData(buffer *bytes.Buffer, values []interface{}) {
+	if !hasField(values) {
+		buffer.WriteByte('-')
+		return
+	}
+	scratch := getBuffer()
+	defer putBuffer(scratch)
+	buffer.WriteByte('[')
+	buffer.WriteString(sc.SDID)
+	eachField(values, func(field Field) {
+		if field.Key == TimeKey {
+			return
+		}
+		buffer.WriteByte(' ')
+		writeSDName(buffer, field.Key)
+		buffer.WriteString(`="`)
+		scratch.Reset()
+		field.writeText(scratch, "")
+		for _, c := range scratch.Bytes() {
+			if c == '"' || c == '\\' || c == ']' {
+				buffer.WriteByte('\\')
+			}
+			buffer.WriteByte(c)
+		}
+		buffer.WriteByte('"')
+	})
+	buffer.WriteByte(']')
+}
+
+// writeSDName writes a structured data parameter name,
+// replacing the forbidden characters by '_' and truncating it to 32 characters.
+func writeSDName(buffer *bytes.Buffer, name string) {
+	if name == "" {
+		name = "_"
+	}
+	for i := 0; i < len(name) && i < 32; i++ {
+		c := name[i]
+		if c <= ' ' || c > '~' || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		buffer.WriteByte(c)
+	}
+}
+
+// writeSyslogHeader writes a header field, "-" if empty,
+// truncated to maxLength characters.
+func writeSyslogHeader(buffer *bytes.Buffer, header string, maxLength int) {
+	if header == "" {
+		buffer.WriteByte('-')
+		return
+	}
+	for i := 0; i < len(header) && i < maxLength; i++ {
+		c := header[i]
+		if c <= ' ' || c > '~' {
+			c = '_'
+		}
+		buffer.WriteByte(c)
+	}
+}
+
+// Close the connection to the server.
+// Later calls panic.
+func (sc *SyslogContext) Close() error {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	if sc.closed {
+		return fmt.Errorf("trying to close already closed syslog connection %s", sc.Address)
+	}
+	sc.closed = true
+	if sc.conn == nil {
+		// the last reconnection failed
+		return nil
+	}
+	err := sc.conn.Close()
+	sc.conn = nil
+	return err
+}
+
+// Health reports whether the connection is open.
+func (sc *SyslogContext) Health() SinkHealth {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	if sc.conn == nil {
+		return SinkHealth{}
+	}
+	return SinkHealth{OpenFiles: 1}
+}
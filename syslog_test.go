@@ -0,0 +1,187 @@
+package log4g
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyslogEncode(t *testing.T) {
+	sc := SyslogContext{Facility: SyslogLocal0, AppName: "prime", Hostname: "host", SDID: "log4g@32473"}
+	date := time.Date(2018, 11, 29, 0, 38, 11, 0, time.UTC)
+	pid := strconv.Itoa(os.Getpid())
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+	sc.encode(buffer, date, ERROR, []interface{}{"is", "prime", Int("n", 41), String("quote", `a"b]`)})
+	assert.Equal(t, `<131>1 2018-11-29T00:38:11.000000Z host prime `+pid+` - [log4g@32473 n="41" quote="a\"b\]"] is prime`, buffer.String())
+	buffer.Reset()
+	sc.encode(buffer, date, "custom", []interface{}{"custom"})
+	assert.Equal(t, `<134>1 2018-11-29T00:38:11.000000Z host prime `+pid+` - - custom`, buffer.String())
+	buffer.Reset()
+	sc.encode(buffer, time.Now(), DEBUG, []interface{}{String("bad key=", "v"), Time(TimeKey, date)})
+	assert.Equal(t, `<135>1 2018-11-29T00:38:11.000000Z host prime `+pid+` - [log4g@32473 bad_key_="v"]`, buffer.String())
+	sc.AppName = strings.Repeat("a", 60)
+	buffer.Reset()
+	sc.encode(buffer, date, INFO, []interface{}{"long"})
+	assert.Equal(t, `<134>1 2018-11-29T00:38:11.000000Z host `+strings.Repeat("a", 48)+` `+pid+` - - long`, buffer.String())
+	sc.AppName = "prime"
+	sc.Format = RFC3164
+	buffer.Reset()
+	sc.encode(buffer, date, FATAL, []interface{}{"down", Int("n", 41)})
+	assert.Equal(t, `<130>Nov 29 00:38:11 host prime[`+pid+`]: down n=41`, buffer.String())
+}
+
+func TestSyslogUDP(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer server.Close()
+	sc := SyslogContext{Network: "udp", Address: server.LocalAddr().String(), AppName: "prime", Hostname: "host"}
+	assert.Nil(t, sc.Init())
+	assert.Equal(t, 1, sc.Health().OpenFiles)
+	sc.Logger(WARN, "is prime", Int("n", 41))
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	message := make([]byte, 1024)
+	n, _, err := server.ReadFrom(message)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(message[:n]), "<12>1 "), string(message[:n]))
+	assert.True(t, strings.HasSuffix(string(message[:n]), ` - [log4g@32473 n="41"] is prime`), string(message[:n]))
+	assert.Nil(t, sc.Close())
+	assert.NotNil(t, sc.Close())
+	assert.Equal(t, 0, sc.Health().OpenFiles)
+	assert.EqualError(t, sc.NoPanic(INFO, "closed"), "writing to closed syslog connection "+sc.Address)
+}
+
+func TestSyslogTimeout(t *testing.T) {
+	server, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer server.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := server.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+	sc := SyslogContext{Network: "tcp", Address: server.Addr().String(), Timeout: 50 * time.Millisecond}
+	assert.Nil(t, sc.Init())
+	defer sc.Close()
+	assert.Equal(t, 50*time.Millisecond, sc.Timeout)
+	conn := <-accepted
+	defer conn.Close()
+	// the server never reads: the writes fill the socket buffers then time out
+	message := strings.Repeat("x", 1024*1024)
+	for i := 0; i < 64; i++ {
+		start := time.Now()
+		sc.Logger.NoPanic(INFO, message)
+		assert.True(t, time.Since(start) < 2*time.Second, time.Since(start))
+	}
+
+	udp := SyslogContext{Network: "udp", Address: "127.0.0.1:9"}
+	assert.Nil(t, udp.Init())
+	assert.Equal(t, 5*time.Second, udp.Timeout)
+	assert.Nil(t, udp.Close())
+}
+
+func TestSyslogFacility(t *testing.T) {
+	for _, facility := range []int{-1, 24} {
+		sc := SyslogContext{Network: "udp", Address: "127.0.0.1:514", Facility: facility}
+		assert.EqualError(t, sc.Init(), "invalid syslog facility "+strconv.Itoa(facility))
+	}
+}
+
+// readOctetFrame reads an octet counted syslog message.
+func readOctetFrame(reader *bufio.Reader) (string, error) {
+	length, err := reader.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+	if err != nil {
+		return "", err
+	}
+	message := make([]byte, n)
+	_, err = io.ReadFull(reader, message)
+	return string(message), err
+}
+
+func TestSyslogTCPReconnect(t *testing.T) {
+	server, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer server.Close()
+	messages := make(chan string, 16)
+	go func() {
+		for i := 0; ; i++ {
+			conn, err := server.Accept()
+			if err != nil {
+				return
+			}
+			reader := bufio.NewReader(conn)
+			message, err := readOctetFrame(reader)
+			if err == nil {
+				messages <- message
+			}
+			if i == 0 {
+				// drop the first connection after a message
+				conn.Close()
+				continue
+			}
+			for {
+				message, err := readOctetFrame(reader)
+				if err != nil {
+					break
+				}
+				messages <- message
+			}
+			conn.Close()
+		}
+	}()
+	sc := SyslogContext{Network: "tcp", Address: server.Addr().String(), AppName: "prime", Hostname: "host"}
+	assert.Nil(t, sc.Init())
+	defer sc.Close()
+	sc.Logger(INFO, "first")
+	assert.True(t, strings.HasSuffix(<-messages, " - - first"))
+	deadline := time.After(5 * time.Second)
+	for i := 0; ; i++ {
+		sc.Logger(INFO, "again", i)
+		select {
+		case message := <-messages:
+			assert.True(t, strings.Contains(message, " - - again "), message)
+			return
+		case <-deadline:
+			t.Fatal("no message after reconnection")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestSyslogUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syslog")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	address := filepath.Join(dir, "log")
+	server, err := net.ListenPacket("unixgram", address)
+	assert.Nil(t, err)
+	defer server.Close()
+	sc := SyslogContext{Address: address, Format: RFC3164, AppName: "prime", Hostname: "host"}
+	assert.Nil(t, sc.Init())
+	defer sc.Close()
+	sc.Logger(DEBUG, "local")
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	message := make([]byte, 1024)
+	n, _, err := server.ReadFrom(message)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(message[:n]), "<15>"))
+	assert.True(t, strings.HasSuffix(string(message[:n]), "]: local"))
+
+	missing := SyslogContext{Address: filepath.Join(dir, "missing")}
+	assert.NotNil(t, missing.Init())
+}
@@ -0,0 +1,41 @@
+package log4g
+
+import (
+	"fmt"
+	"time"
+)
+
+// TraceIndent is prepended to the calls logged inside a traced function.
+var TraceIndent = "  "
+
+// Enter logs the call of the calling function with its arguments at TRACE level.
+// It returns the logger to use inside the function, which indents the calls under the entry,
+// and the function to defer for logging the exit with the elapsed time and the results.
+// The exit is logged at ERROR level if one of the results is a non nil error.
+//
+//	func isPrime(n int, logger Logger) (prime bool) {
+//		logger, exit := logger.Enter(n)
+//		defer func() { exit(prime) }()
+func (logger Logger) Enter(args ...interface{}) (Logger, func(results ...interface{})) {
+	return logger.EnterSkip(1, args...)
+}
+
+// EnterSkip is Enter skipping skip stack frames,
+// for helpers tracing the function call of their caller.
+func (logger Logger) EnterSkip(skip int, args ...interface{}) (Logger, func(results ...interface{})) {
+	funcName := shortFuncName(callerFrame(skip).Function)
+	call := fmt.Sprintf("%s %v", funcName, args)
+	start := time.Now()
+	logger(TRACE, "-> "+call)
+	return logger.Prepend(TraceIndent), func(results ...interface{}) {
+		level := TRACE
+		for _, result := range results {
+			if err, ok := result.(error); ok && err != nil {
+				level = ERROR
+			}
+		}
+		values := make([]interface{}, 0, len(results)+2)
+		values = append(values, "<- "+call, Duration("elapsed", time.Since(start)))
+		logger(level, append(values, results...)...)
+	}
+}
@@ -0,0 +1,57 @@
+package log4g
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tracedIsFactor(n int, f int, logger Logger) (isFactor bool) {
+	logger, exit := logger.Enter(n, f)
+	defer func() { exit(isFactor) }()
+	isFactor = n%f == 0
+	logger(DEBUG, "remainder", n%f)
+	return isFactor
+}
+
+func tracedIsPrime(n int, logger Logger) (prime bool, err error) {
+	logger, exit := logger.Enter(n)
+	defer func() { exit(prime, err) }()
+	if n < 2 {
+		return false, errors.New("too small")
+	}
+	for f := 2; f < n; f++ {
+		if tracedIsFactor(n, f, logger) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func TestEnter(t *testing.T) {
+	logger, buffer := NewInMemoryLogger()
+	tracedIsPrime(3, logger)
+	tracedIsPrime(1, logger)
+	lines := buffer.StringArray(" ")
+	expected := []string{
+		"[TRACE] -> tracedIsPrime [3] ",
+		"[TRACE]    -> tracedIsFactor [3 2] ",
+		"[DEBUG]       remainder 1 ",
+		"[TRACE]    <- tracedIsFactor [3 2] elapsed=",
+		"[TRACE] <- tracedIsPrime [3] elapsed=",
+		"[TRACE] -> tracedIsPrime [1] ",
+		"[ERROR] <- tracedIsPrime [1] elapsed=",
+	}
+	assert.Equal(t, len(expected), len(lines))
+	for i, line := range expected {
+		assert.Contains(t, lines[i], line)
+	}
+	assert.Contains(t, lines[3], " false ")
+	assert.Contains(t, lines[4], " true <nil> ")
+	assert.Contains(t, lines[6], " false too small ")
+	elapsed := (*buffer)[4][2].(Field)
+	assert.Equal(t, "elapsed", elapsed.Key)
+	assert.True(t, elapsed.Value().(time.Duration) >= 0)
+}
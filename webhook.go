@@ -0,0 +1,271 @@
+package log4g
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// DefaultWebhookTemplate is the default payload of the webhook requests.
+const DefaultWebhookTemplate = `{"alerts":[{{range $i, $alert := .Alerts}}{{if $i}},{{end}}` +
+	`{"level":{{json $alert.Level}},"message":{{json $alert.Message}},"count":{{$alert.Count}},` +
+	`"first":{{json $alert.First}},"last":{{json $alert.Last}},"fields":{{json $alert.Fields}}}{{end}}]}`
+
+// WebhookAlert is a record sent to a webhook.
+// Identical records of a batching window are sent once with their count.
+type WebhookAlert struct {
+	// Level is the level name, such as ERROR.
+	Level string
+	// Message are the values that aren't fields.
+	Message string
+	// Fields are the fields but the time.
+	Fields map[string]interface{}
+	// Count is the number of identical records.
+	Count int
+	// First and Last are the times of the first and the last identical records.
+	First time.Time
+	Last  time.Time
+}
+
+// WebhookBatch is the data of the payload template.
+type WebhookBatch struct {
+	Alerts []WebhookAlert
+}
+
+// webhookRecord is a logger call waiting in the queue.
+type webhookRecord struct {
+	key   string
+	alert WebhookAlert
+}
+
+// newWebhookRecord converts a logger call to a webhookRecord.
+// The key identifies the identical records: same level, message and fields but the time.
+func newWebhookRecord(now time.Time, level string, values []interface{}) webhookRecord {
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+	writeMessage(buffer, values, "")
+	alert := WebhookAlert{
+		Level:   LevelName(level),
+		Message: buffer.String(),
+		Count:   1,
+		First:   now,
+		Last:    now,
+	}
+	buffer.WriteByte(0)
+	buffer.WriteString(alert.Level)
+	eachField(values, func(field Field) {
+		value := field.Value()
+		if field.Key == TimeKey {
+			if t, ok := value.(time.Time); ok {
+				alert.First, alert.Last = t, t
+				return
+			}
+		}
+		if alert.Fields == nil {
+			alert.Fields = make(map[string]interface{})
+		}
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		alert.Fields[field.Key] = value
+		buffer.WriteByte(0)
+		writeValue(buffer, field, "")
+	})
+	return webhookRecord{key: buffer.String(), alert: alert}
+}
+
+// webhookFuncs are the functions of the payload template.
+var webhookFuncs = template.FuncMap{
+	// json encodes a value as JSON, values not supported by encoding/json as a string.
+	"json": func(value interface{}) (string, error) {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			encoded, err = json.Marshal(fmt.Sprint(value))
+		}
+		return string(encoded), err
+	},
+}
+
+// WebhookContext stores the data for posting logged values to a webhook,
+// such as a chat or an incident tool.
+// Calls never block: they are queued, batched over a window and deduplicated,
+// then posted in the background.
+// Usually composed with Threshold(ERROR).
+type WebhookContext struct {
+	// dropped is first for 64-bit atomic alignment on 32-bit platforms.
+	dropped uint64
+	Logger
+	// URL of the webhook.
+	URL string
+	// Template is a text/template of the JSON payload executed with a WebhookBatch.
+	// The json function encodes a value as JSON.
+	// Defaults to DefaultWebhookTemplate if field empty.
+	Template string
+	// Headers are added to the requests.
+	Headers map[string]string
+	// Client posts the requests.
+	// Defaults to an http.Client with a Timeout timeout if field empty.
+	Client *http.Client
+	// Timeout bounds each request.
+	// Defaults to 10 seconds if field empty.
+	Timeout time.Duration
+	// Window is the batching window, opened by the first record of a batch.
+	// Defaults to 10 seconds if field empty.
+	Window time.Duration
+	// MaxRetries is the number of retries of a failed request.
+	// Defaults to 3 if field empty.
+	MaxRetries int
+	// Backoff is the wait before the first retry, doubled on each retry.
+	// Defaults to 1 second if field empty.
+	Backoff time.Duration
+	// QueueSize is the max number of records waiting for a batch,
+	// calls are dropped when the queue is full.
+	// Defaults to 256 if field empty.
+	QueueSize int
+	// ErrorHandler is called with the errors of the batches dropped after the retries.
+	ErrorHandler func(error)
+	template     *template.Template
+	queue        chan webhookRecord
+	done         chan struct{}
+	closed       bool
+	lock         sync.RWMutex
+	pending      int32
+}
+
+// Init parses the template and starts posting.
+func (wc *WebhookContext) Init() error {
+	if wc.URL == "" {
+		return fmt.Errorf("missing webhook URL")
+	}
+	if wc.Template == "" {
+		wc.Template = DefaultWebhookTemplate
+	}
+	tmpl, err := template.New("webhook").Funcs(webhookFuncs).Parse(wc.Template)
+	if err != nil {
+		return err
+	}
+	wc.template = tmpl
+	if wc.Timeout <= 0 {
+		wc.Timeout = 10 * time.Second
+	}
+	if wc.Client == nil {
+		wc.Client = &http.Client{Timeout: wc.Timeout}
+	}
+	if wc.Window <= 0 {
+		wc.Window = 10 * time.Second
+	}
+	if wc.MaxRetries <= 0 {
+		wc.MaxRetries = 3
+	}
+	if wc.Backoff <= 0 {
+		wc.Backoff = time.Second
+	}
+	if wc.QueueSize <= 0 {
+		wc.QueueSize = 256
+	}
+	wc.queue = make(chan webhookRecord, wc.QueueSize)
+	wc.done = make(chan struct{})
+	wc.closed = false
+	wc.Logger = func(level string, values ...interface{}) {
+		record := newWebhookRecord(time.Now(), level, values)
+		wc.lock.RLock()
+		defer wc.lock.RUnlock()
+		if wc.closed {
+			atomic.AddUint64(&wc.dropped, 1)
+			return
+		}
+		select {
+		case wc.queue <- record:
+		default:
+			atomic.AddUint64(&wc.dropped, 1)
+		}
+	}
+	go wc.run()
+	return nil
+}
+
+// run batches the queued records until the webhook is closed.
+func (wc *WebhookContext) run() {
+	defer close(wc.done)
+	var alerts []WebhookAlert
+	records := 0
+	indexes := make(map[string]int)
+	var window <-chan time.Time
+	flush := func() {
+		if len(alerts) > 0 {
+			wc.post(alerts, records)
+		}
+		alerts, records = nil, 0
+		indexes = make(map[string]int)
+		window = nil
+		atomic.StoreInt32(&wc.pending, 0)
+	}
+	for {
+		select {
+		case record, ok := <-wc.queue:
+			if !ok {
+				flush()
+				return
+			}
+			i, found := indexes[record.key]
+			if found {
+				alerts[i].Count++
+				alerts[i].Last = record.alert.Last
+			} else {
+				indexes[record.key] = len(alerts)
+				alerts = append(alerts, record.alert)
+			}
+			records++
+			atomic.StoreInt32(&wc.pending, int32(records))
+			if window == nil {
+				window = time.After(wc.Window)
+			}
+		case <-window:
+			flush()
+		}
+	}
+}
+
+// post executes the template and posts the payload.
+// records is the number of records of the batch, duplicates included.
+func (wc *WebhookContext) post(alerts []WebhookAlert, records int) {
+	var body bytes.Buffer
+	err := wc.template.Execute(&body, WebhookBatch{Alerts: alerts})
+	if err == nil {
+		err = postJSON(wc.Client, wc.URL, wc.Headers, body.Bytes(), wc.MaxRetries, wc.Backoff)
+	}
+	if err != nil {
+		atomic.AddUint64(&wc.dropped, uint64(records))
+		if wc.ErrorHandler != nil {
+			wc.ErrorHandler(err)
+		}
+	}
+}
+
+// Close posts the batched records and stops the webhook.
+// Later calls are dropped.
+func (wc *WebhookContext) Close() error {
+	wc.lock.Lock()
+	if wc.queue == nil || wc.closed {
+		wc.lock.Unlock()
+		return fmt.Errorf("trying to close already closed webhook %s", wc.URL)
+	}
+	wc.closed = true
+	close(wc.queue)
+	wc.lock.Unlock()
+	<-wc.done
+	return nil
+}
+
+// Health reports the queued and dropped records.
+func (wc *WebhookContext) Health() SinkHealth {
+	return SinkHealth{
+		QueueDepth: len(wc.queue) + int(atomic.LoadInt32(&wc.pending)),
+		Dropped:    atomic.LoadUint64(&wc.dropped),
+	}
+}
@@ -0,0 +1,154 @@
+package log4g
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// webhookServer is an httptest stand-in for a webhook.
+type webhookServer struct {
+	lock     sync.Mutex
+	bodies   []string
+	failures int
+}
+
+func (ws *webhookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+	if ws.failures > 0 {
+		ws.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	ws.bodies = append(ws.bodies, string(body))
+}
+
+func TestWebhookContext(t *testing.T) {
+	ws := &webhookServer{failures: 1}
+	server := httptest.NewServer(ws)
+	defer server.Close()
+	wc := WebhookContext{
+		URL:     server.URL,
+		Window:  time.Hour,
+		Backoff: time.Millisecond,
+	}
+	assert.Nil(t, wc.Init())
+	logger := wc.Logger.Threshold(ERROR)
+	date := time.Date(2018, 11, 29, 0, 38, 11, 0, time.UTC)
+	logger(INFO, "not an alert")
+	for i := 0; i < 3; i++ {
+		logger(ERROR, "disk full", String("disk", "/dev/sda"), Time(TimeKey, date.Add(time.Duration(i)*time.Second)))
+	}
+	logger(FATAL, "down", Err(errors.New("timeout")))
+	assert.Nil(t, wc.Close())
+	assert.NotNil(t, wc.Close())
+
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+	assert.Equal(t, 1, len(ws.bodies))
+	var payload struct {
+		Alerts []struct {
+			Level   string
+			Message string
+			Count   int
+			First   time.Time
+			Last    time.Time
+			Fields  map[string]interface{}
+		}
+	}
+	assert.Nil(t, json.Unmarshal([]byte(ws.bodies[0]), &payload))
+	assert.Equal(t, 2, len(payload.Alerts))
+	alert := payload.Alerts[0]
+	assert.Equal(t, "ERROR", alert.Level)
+	assert.Equal(t, "disk full", alert.Message)
+	assert.Equal(t, 3, alert.Count)
+	assert.Equal(t, date, alert.First)
+	assert.Equal(t, date.Add(2*time.Second), alert.Last)
+	assert.Equal(t, map[string]interface{}{"disk": "/dev/sda"}, alert.Fields)
+	assert.Equal(t, "FATAL", payload.Alerts[1].Level)
+	assert.Equal(t, map[string]interface{}{"error": "timeout"}, payload.Alerts[1].Fields)
+	assert.Equal(t, SinkHealth{}, wc.Health())
+}
+
+func TestWebhookContextWindow(t *testing.T) {
+	ws := &webhookServer{}
+	server := httptest.NewServer(ws)
+	defer server.Close()
+	wc := WebhookContext{
+		URL:      server.URL,
+		Window:   10 * time.Millisecond,
+		Template: `{"blocks":[{{range $i, $alert := .Alerts}}{{if $i}},{{end}}{"text":{{json $alert.Message}},"level":{{json $alert.Level}},"count":{{$alert.Count}}}{{end}}]}`,
+	}
+	assert.Nil(t, wc.Init())
+	defer wc.Close()
+	wc.Logger(ERROR, "first")
+	wc.Logger(ERROR, "first")
+	assert.Eventually(t, func() bool {
+		ws.lock.Lock()
+		defer ws.lock.Unlock()
+		return len(ws.bodies) == 1
+	}, 5*time.Second, time.Millisecond)
+	wc.Logger(ERROR, `"second"`)
+	assert.Eventually(t, func() bool {
+		ws.lock.Lock()
+		defer ws.lock.Unlock()
+		return len(ws.bodies) == 2
+	}, 5*time.Second, time.Millisecond)
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+	assert.Equal(t, []string{
+		`{"blocks":[{"text":"first","level":"ERROR","count":2}]}`,
+		`{"blocks":[{"text":"\"second\"","level":"ERROR","count":1}]}`,
+	}, ws.bodies)
+}
+
+func TestWebhookContextErrors(t *testing.T) {
+	assert.NotNil(t, (&WebhookContext{}).Init())
+	assert.NotNil(t, (&WebhookContext{URL: "http://localhost", Template: "{{"}).Init())
+
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	errs := make(chan error, 2)
+	wc := WebhookContext{
+		URL:          server.URL,
+		Window:       time.Millisecond,
+		QueueSize:    1,
+		ErrorHandler: func(err error) { errs <- err },
+	}
+	assert.Nil(t, wc.Init())
+	wc.Logger(ERROR, "posted")
+	<-started
+	// the server blocks the first post, calls don't wait
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			wc.Logger(ERROR, "queued", i)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logger blocked")
+	}
+	close(release)
+	assert.NotNil(t, <-errs)
+	assert.Nil(t, wc.Close())
+	assert.NotNil(t, <-errs)
+	assert.Equal(t, uint64(11), wc.Health().Dropped)
+}